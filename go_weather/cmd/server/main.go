@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"goweather/internal/clients"
 	"goweather/internal/config"
 	"goweather/internal/database"
 	"goweather/internal/handlers"
 	"goweather/internal/logger"
+	"goweather/internal/metrics"
+	"goweather/internal/middleware"
 	"goweather/internal/services"
+	"goweather/internal/tracing"
 	"goweather/pkg/types"
 )
 
@@ -19,16 +28,39 @@ func main() {
 	
 	cfg := config.LoadConfig()
 	
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "tenant" {
+		os.Exit(runTenant(cfg, os.Args[2:]))
+	}
+	
 	log.Info().
 		Str("component", "server").
 		Str("action", "startup").
 		Str("port", cfg.ServerPort).
+		Str("database_driver", cfg.DatabaseDriver).
 		Str("database_path", cfg.DatabasePath).
 		Int("max_requests", cfg.MaxRequests).
+		Strs("providers", cfg.Providers).
 		Bool("debug_mode", cfg.DebugMode).
 		Msg("Starting weather API server")
 	
-	db, err := database.NewDatabase(cfg.DatabasePath)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatal().
+			Str("component", "server").
+			Str("action", "tracing_setup_failed").
+			Err(err).
+			Msg("Tracing setup failed")
+	}
+	
+	db, err := openStore(cfg)
 	if err != nil {
 		log.Fatal().
 			Str("component", "server").
@@ -39,8 +71,24 @@ func main() {
 	defer db.Close()
 	
 	
-	weatherService := services.NewWeatherService(db, cfg)
-	weatherHandler := handlers.NewWeatherHandler(weatherService)
+	providers, err := clients.NewRegistryFromConfig(cfg)
+	if err != nil {
+		log.Fatal().
+			Str("component", "server").
+			Str("action", "provider_setup_failed").
+			Err(err).
+			Msg("Weather provider setup failed")
+	}
+	
+	weatherService := services.NewWeatherService(db, providers, cfg)
+	
+	// API key -> tenant önce çözülür: rate limit tenant'a göre, quota en son sayılır
+	authenticator := middleware.NewAuthenticator(db, middleware.AuthConfig{
+		Required: cfg.AuthRequired,
+		CacheTTL: cfg.AuthCacheTTL,
+	})
+	weatherHandler := handlers.NewWeatherHandler(weatherService, authenticator.Charge)
+	healthHandler := handlers.NewHealthHandler(db, weatherService, cfg.ReadyQueueThreshold)
 
 	log.Debug().
 		Str("component", "server").
@@ -60,7 +108,7 @@ func main() {
 		http.HandleFunc("/queries", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 			
-			queries, err := db.GetWeatherQueries()
+			queries, err := db.GetWeatherQueries(r.Context())
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				errorResp := types.ErrorResponse{
@@ -75,7 +123,55 @@ func main() {
 		})
 	}
 	
-	http.HandleFunc("/weather", weatherHandler.GetWeather)
+	// Weather endpoint'leri istemci başına token bucket ile sınırlanır
+	limit := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	if cfg.RateLimitEnabled {
+		limit = middleware.NewRateLimiter(middleware.RateLimitConfig{
+			Rate:       cfg.RateLimitRate,
+			Burst:      cfg.RateLimitBurst,
+			IdleTTL:    cfg.RateLimitIdleTTL,
+			TrustProxy: cfg.RateLimitTrustProxy,
+		}).Limit
+	}
+	
+	guard := func(next http.HandlerFunc) http.HandlerFunc {
+		return authenticator.Authenticate(limit(authenticator.Quota(next)))
+	}
+	
+	http.HandleFunc("/weather", middleware.Trace("/weather", guard(weatherHandler.GetWeather)))
+	// batch quota'yı lokasyon sayısına göre handler içinde keser
+	http.HandleFunc("/weather/batch", middleware.Trace("/weather/batch", authenticator.Authenticate(limit(weatherHandler.GetWeatherBatch))))
+	http.HandleFunc("/forecast", middleware.Trace("/forecast", guard(weatherHandler.GetForecast)))
+	
+	// Geçmiş her zaman kimlik ister: API key (kendi tenant'ı) ya da admin token (hepsi).
+	// Okumalar quota'dan düşülmez, yoksa quota'sı biten tenant geçmişini de göremez
+	historyGuard := func(next http.HandlerFunc) http.HandlerFunc {
+		return authenticator.Authenticate(limit(next))
+	}
+	historyHandler := handlers.NewHistoryHandler(weatherService, cfg.AdminToken)
+	http.HandleFunc("/history", middleware.Trace("/history", historyHandler.Authorize(historyGuard, historyHandler.History)))
+	http.HandleFunc("/history/stats", middleware.Trace("/history/stats", historyHandler.Authorize(historyGuard, historyHandler.Stats)))
+	
+	http.HandleFunc("/status/providers", weatherHandler.GetProviderStatus)
+	http.HandleFunc("/healthz", healthHandler.Liveness)
+	http.HandleFunc("/readyz", healthHandler.Readiness)
+	
+	if cfg.MetricsEnabled {
+		http.Handle("/metrics", metrics.Handler())
+	}
+	
+	// Admin API sadece ADMIN_TOKEN verildiğinde açılır
+	if cfg.AdminToken != "" {
+		adminHandler := handlers.NewAdminHandler(weatherService, cfg.AdminToken)
+		http.HandleFunc("/admin/groups", middleware.Trace("/admin/groups", adminHandler.Authenticate(adminHandler.Groups)))
+		http.HandleFunc("/admin/groups/flush", middleware.Trace("/admin/groups/flush", adminHandler.Authenticate(adminHandler.Flush)))
+		http.HandleFunc("/admin/aggregation", middleware.Trace("/admin/aggregation", adminHandler.Authenticate(adminHandler.Aggregation)))
+	} else {
+		log.Info().
+			Str("component", "server").
+			Str("action", "admin_disabled").
+			Msg("ADMIN_TOKEN not set, admin API disabled")
+	}
 	
 	
 	port := ":" + cfg.ServerPort
@@ -86,11 +182,110 @@ func main() {
 		Str("test_url", fmt.Sprintf("http://localhost%s/weather?q=Istanbul", port)).
 		Msg("Server ready to accept requests")
 	
-	if err := http.ListenAndServe(port, nil); err != nil {
-		log.Fatal().
+	// Her isteğe X-Request-ID; log satırları ve span'ler bununla eşleşir
+	server := &http.Server{Addr: port, Handler: middleware.RequestID(http.DefaultServeMux)}
+	
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	
+	// Sağlıksız provider'lar trafik olmasa da yoklanır, yoksa /readyz kendini toparlayamaz
+	go clients.NewProber(providers, clients.ProbeConfig{
+		Interval: cfg.ProviderProbeInterval,
+		Location: cfg.ProviderProbeLocation,
+	}).Run(ctx)
+	
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().
+				Str("component", "server").
+				Str("action", "server_start_failed").
+				Err(err).
+				Msg("Server failed to start")
+		}
+	case <-ctx.Done():
+	}
+	
+	log.ServerShutdown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	
+	// Fire waiting batches now so in-flight handlers can return before the deadline
+	weatherService.FlushAll()
+	
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().
+			Str("component", "server").
+			Str("action", "shutdown_error").
+			Err(err).
+			Msg("HTTP server did not shut down cleanly")
+	}
+	
+	if err := weatherService.Shutdown(shutdownCtx); err != nil {
+		log.Error().
 			Str("component", "server").
-			Str("action", "server_start_failed").
+			Str("action", "drain_error").
 			Err(err).
-			Msg("Server failed to start")
+			Msg("Weather service did not drain before deadline")
+	}
+	
+	// last, so spans of the final batches and database writes are exported
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error().
+			Str("component", "server").
+			Str("action", "tracing_shutdown_error").
+			Err(err).
+			Msg("Buffered spans not exported")
+	}
+	
+	log.Info().
+		Str("component", "server").
+		Str("action", "stopped").
+		Msg("Server stopped")
+}
+
+// serverStore is what the server needs from the backend: the fetch log and
+// the tenants API keys resolve to.
+type serverStore interface {
+	database.Store
+	middleware.TenantStore
+}
+
+// openStore opens the backend selected by DATABASE_DRIVER for the server,
+// with pending migrations applied.
+func openStore(cfg *config.Config) (serverStore, error) {
+	db, err := openDatabase(cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// openDatabase connects to the SQL database selected by DATABASE_DRIVER,
+// for the subcommands that manage its schema and tenants. With migrate set,
+// pending migrations are applied first; the migrate subcommand opens
+// without them.
+func openDatabase(cfg *config.Config, migrate bool) (*database.Database, error) {
+	switch cfg.DatabaseDriver {
+	case database.DriverSQLite:
+		if migrate {
+			return database.NewDatabase(cfg.DatabasePath)
+		}
+		return database.Open(cfg.DatabasePath)
+	case database.DriverPostgres:
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required when DATABASE_DRIVER=postgres")
+		}
+		if migrate {
+			return database.NewPostgres(cfg.DatabaseURL)
+		}
+		return database.OpenPostgres(cfg.DatabaseURL)
+	default:
+		return nil, fmt.Errorf("unknown DATABASE_DRIVER %q (want sqlite or postgres)", cfg.DatabaseDriver)
 	}
-}
\ No newline at end of file
+}
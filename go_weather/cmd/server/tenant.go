@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"goweather/internal/config"
+	"goweather/internal/database"
+	"goweather/pkg/types"
+)
+
+const tenantUsage = `usage: goweather tenant <command>
+
+commands:
+  list                                  list tenants and their quotas
+  create <id> <name> [daily] [monthly]  add a tenant; quotas default to 0 (unlimited)
+  quota <id> <daily> <monthly>          change a tenant's quotas
+  usage <id>                            show today's and this month's request counts
+  key create <id>                       issue an API key (shown only once)
+  key list <id>                         list a tenant's keys
+  key revoke <key-id>                   revoke a key`
+
+// runTenant implements the "tenant" subcommand and returns the exit code.
+func runTenant(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tenantUsage)
+		return 2
+	}
+
+	db, err := openDatabase(cfg, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tenant: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "list":
+		tenants, err := db.GetTenants(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tenant list: %v\n", err)
+			return 1
+		}
+		for _, tenant := range tenants {
+			fmt.Printf("%-20s %-30s daily=%-8s monthly=%s\n", tenant.ID, tenant.Name,
+				quotaString(tenant.DailyQuota), quotaString(tenant.MonthlyQuota))
+		}
+
+	case args[0] == "create" && len(args) >= 3:
+		quotas, ok := parseQuotas(args[3:])
+		if !ok {
+			fmt.Fprintln(os.Stderr, tenantUsage)
+			return 2
+		}
+		tenant := &types.Tenant{ID: args[1], Name: args[2], DailyQuota: quotas[0], MonthlyQuota: quotas[1]}
+		if err := db.CreateTenant(ctx, tenant); err != nil {
+			fmt.Fprintf(os.Stderr, "tenant create: %v\n", err)
+			return 1
+		}
+		fmt.Printf("tenant %s created\n", tenant.ID)
+
+	case args[0] == "quota" && len(args) == 4:
+		quotas, ok := parseQuotas(args[2:])
+		if !ok {
+			fmt.Fprintln(os.Stderr, tenantUsage)
+			return 2
+		}
+		if err := db.SetTenantQuota(ctx, args[1], quotas[0], quotas[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "tenant quota: %v\n", err)
+			return 1
+		}
+		fmt.Printf("tenant %s quotas set to daily=%s monthly=%s\n", args[1], quotaString(quotas[0]), quotaString(quotas[1]))
+
+	case args[0] == "usage" && len(args) == 2:
+		usage, err := db.Usage(ctx, args[1], time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tenant usage: %v\n", err)
+			return 1
+		}
+		fmt.Printf("%s  %s: %d requests  %s: %d requests\n", usage.TenantID, usage.Day, usage.DayCount, usage.Month, usage.MonthCount)
+
+	case args[0] == "key" && len(args) == 3:
+		return runTenantKey(ctx, db, args[1], args[2])
+
+	default:
+		fmt.Fprintln(os.Stderr, tenantUsage)
+		return 2
+	}
+	return 0
+}
+
+func runTenantKey(ctx context.Context, db *database.Database, command, arg string) int {
+	switch command {
+	case "create":
+		key, apiKey, err := db.CreateAPIKey(ctx, arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tenant key create: %v\n", err)
+			return 1
+		}
+		fmt.Printf("key %d for tenant %s (store it now, it is not shown again):\n%s\n", apiKey.ID, apiKey.TenantID, key)
+
+	case "list":
+		keys, err := db.GetAPIKeys(ctx, arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tenant key list: %v\n", err)
+			return 1
+		}
+		for _, key := range keys {
+			state := "active"
+			if key.RevokedAt != nil {
+				state = "revoked " + key.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-6d %s...  created %s  %s\n", key.ID, key.Prefix, key.CreatedAt.Format("2006-01-02 15:04:05"), state)
+		}
+
+	case "revoke":
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tenant key revoke: invalid key id %q\n", arg)
+			return 2
+		}
+		if err := db.RevokeAPIKey(ctx, id); err != nil {
+			fmt.Fprintf(os.Stderr, "tenant key revoke: %v\n", err)
+			return 1
+		}
+		fmt.Printf("key %d revoked\n", id)
+
+	default:
+		fmt.Fprintln(os.Stderr, tenantUsage)
+		return 2
+	}
+	return 0
+}
+
+// parseQuotas reads up to two non-negative quotas (daily, monthly)
+func parseQuotas(args []string) ([2]int, bool) {
+	var quotas [2]int
+	if len(args) > len(quotas) {
+		return quotas, false
+	}
+	for i, arg := range args {
+		quota, err := strconv.Atoi(arg)
+		if err != nil || quota < 0 {
+			return quotas, false
+		}
+		quotas[i] = quota
+	}
+	return quotas, true
+}
+
+func quotaString(quota int) string {
+	if quota == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(quota)
+}
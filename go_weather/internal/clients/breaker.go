@@ -0,0 +1,219 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"goweather/internal/logger"
+	"goweather/pkg/types"
+)
+
+// ErrCircuitOpen is returned without calling upstream while a breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig controls when a provider is cut off and for how long.
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit
+	Cooldown         time.Duration // time spent open before probing again
+	HalfOpenRequests int           // probe calls allowed while half-open
+}
+
+// BreakerStatus is a point-in-time view of a breaker for the status endpoint.
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+}
+
+// CircuitBreaker wraps a WeatherProvider and stops calling it after
+// repeated 5xx responses or transport failures.
+type CircuitBreaker struct {
+	provider WeatherProvider
+	config   BreakerConfig
+	logger   *logger.Logger
+	now      func() time.Time // time.Now, replaced in tests
+
+	mutex            sync.Mutex
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+}
+
+func NewCircuitBreaker(provider WeatherProvider, cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{
+		provider: provider,
+		config:   cfg,
+		logger:   logger.Get(),
+		now:      time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.provider.Name()
+}
+
+func (b *CircuitBreaker) GetCurrent(ctx context.Context, location string) (*types.Conditions, error) {
+	if !b.allow() {
+		return nil, fmt.Errorf("%s: %w", b.provider.Name(), ErrCircuitOpen)
+	}
+
+	conditions, err := b.provider.GetCurrent(ctx, location)
+	b.after(ctx, err)
+	return conditions, err
+}
+
+// GetForecast goes through the same breaker as GetCurrent; a provider
+// without forecasts is never called.
+func (b *CircuitBreaker) GetForecast(ctx context.Context, location string, days int) ([]types.ForecastDay, error) {
+	forecaster, ok := b.provider.(ForecastProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: forecasts not supported", b.provider.Name())
+	}
+	if !b.allow() {
+		return nil, fmt.Errorf("%s: %w", b.provider.Name(), ErrCircuitOpen)
+	}
+
+	forecast, err := forecaster.GetForecast(ctx, location, days)
+	b.after(ctx, err)
+	return forecast, err
+}
+
+// Health reports an open circuit before asking the wrapped provider.
+func (b *CircuitBreaker) Health() error {
+	b.mutex.Lock()
+	state := b.state
+	b.mutex.Unlock()
+
+	if state == StateOpen {
+		return ErrCircuitOpen
+	}
+	return b.provider.Health()
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := BreakerStatus{
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.config.Cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			return false
+		}
+		b.transitionLocked(StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.halfOpenInFlight >= b.config.HalfOpenRequests {
+			return false
+		}
+		b.halfOpenInFlight++
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) after(ctx context.Context, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == StateHalfOpen && b.halfOpenInFlight > 0 {
+		b.halfOpenInFlight--
+	}
+
+	// callers going away says nothing about the upstream
+	if ctx.Err() != nil {
+		return
+	}
+
+	if !isBreakerFailure(err) {
+		b.failures = 0
+		if b.state != StateClosed {
+			b.transitionLocked(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.state == StateHalfOpen:
+		b.transitionLocked(StateOpen)
+	case b.state == StateClosed && b.failures >= b.config.FailureThreshold:
+		b.transitionLocked(StateOpen)
+	}
+}
+
+func (b *CircuitBreaker) transitionLocked(to BreakerState) {
+	from := b.state
+	b.state = to
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+	if to != StateHalfOpen {
+		b.halfOpenInFlight = 0
+	}
+	b.logger.CircuitStateChanged(b.provider.Name(), from.String(), to.String(), b.failures)
+}
+
+// isBreakerFailure counts transport errors, timeouts, 429 and 5xx; other
+// 4xx answers mean the upstream is alive and only the request was bad.
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+// fakeProvider answers GetCurrent with err and counts the calls that reach
+// it. While started is set, each call signals it and then waits on release.
+type fakeProvider struct {
+	mutex   sync.Mutex
+	err     error
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) GetCurrent(ctx context.Context, location string) (*types.Conditions, error) {
+	p.mutex.Lock()
+	p.calls++
+	err, started, release := p.err, p.started, p.release
+	p.mutex.Unlock()
+
+	if started != nil {
+		started <- struct{}{}
+		<-release
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &types.Conditions{Temperature: 20}, nil
+}
+
+func (p *fakeProvider) Health() error { return nil }
+
+func (p *fakeProvider) fail(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.err = err
+}
+
+func (p *fakeProvider) callCount() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.calls
+}
+
+// fakeClock is a settable clock for the breaker's cooldown
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(provider WeatherProvider, cfg BreakerConfig) (*CircuitBreaker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker(provider, cfg)
+	breaker.now = clock.Now
+	return breaker, clock
+}
+
+func call(breaker *CircuitBreaker) error {
+	_, err := breaker.GetCurrent(context.Background(), "istanbul")
+	return err
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	provider := &fakeProvider{err: &StatusError{StatusCode: http.StatusBadGateway}}
+	breaker, _ := newTestBreaker(provider, BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if err := call(breaker); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: circuit open before the threshold", i+1)
+		}
+	}
+	if state := breaker.Status().State; state != "open" {
+		t.Fatalf("state after 3 failures = %s, want open", state)
+	}
+
+	if err := call(breaker); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("call while open: err = %v, want ErrCircuitOpen", err)
+	}
+	if provider.callCount() != 3 {
+		t.Errorf("upstream calls = %d, want 3: an open circuit must not call upstream", provider.callCount())
+	}
+	if !errors.Is(breaker.Health(), ErrCircuitOpen) {
+		t.Errorf("Health() = %v, want ErrCircuitOpen", breaker.Health())
+	}
+}
+
+func TestBreakerCountsOnlyUpstreamFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		opens bool
+	}{
+		{"transport error", errors.New("connection refused"), true},
+		{"5xx", &StatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"429", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"400", &StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"404", &StatusError{StatusCode: http.StatusNotFound}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker, _ := newTestBreaker(&fakeProvider{err: tt.err}, BreakerConfig{FailureThreshold: 2})
+			call(breaker)
+			call(breaker)
+			if opened := breaker.Status().State == "open"; opened != tt.opens {
+				t.Errorf("open after 2 calls = %v, want %v", opened, tt.opens)
+			}
+		})
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("timeout")}
+	breaker, _ := newTestBreaker(provider, BreakerConfig{FailureThreshold: 2})
+
+	call(breaker)
+	provider.fail(nil)
+	call(breaker)
+	provider.fail(errors.New("timeout"))
+	call(breaker)
+
+	status := breaker.Status()
+	if status.State != "closed" || status.ConsecutiveFailures != 1 {
+		t.Errorf("status = %s with %d failures, want closed with 1", status.State, status.ConsecutiveFailures)
+	}
+}
+
+func TestBreakerIgnoresCancelledCalls(t *testing.T) {
+	provider := &fakeProvider{err: context.Canceled}
+	breaker, _ := newTestBreaker(provider, BreakerConfig{FailureThreshold: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	breaker.GetCurrent(ctx, "istanbul")
+	if status := breaker.Status(); status.State != "closed" || status.ConsecutiveFailures != 0 {
+		t.Errorf("status after a cancelled call = %+v, want closed without failures", status)
+	}
+}
+
+func TestBreakerHalfOpenAfterCooldown(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial error
+		want  string
+	}{
+		{"trial succeeds", nil, "closed"},
+		{"trial fails", &StatusError{StatusCode: http.StatusInternalServerError}, "open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{err: errors.New("connection reset")}
+			breaker, clock := newTestBreaker(provider, BreakerConfig{FailureThreshold: 1, Cooldown: 30 * time.Second})
+			call(breaker)
+
+			clock.Advance(29 * time.Second)
+			if err := call(breaker); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("call before the cooldown: err = %v, want ErrCircuitOpen", err)
+			}
+
+			clock.Advance(time.Second)
+			provider.fail(tt.trial)
+			call(breaker)
+			if provider.callCount() != 2 {
+				t.Fatalf("upstream calls = %d, want the trial call", provider.callCount())
+			}
+			status := breaker.Status()
+			if status.State != tt.want {
+				t.Fatalf("state after the trial = %s, want %s", status.State, tt.want)
+			}
+			if tt.want == "open" && !status.OpenedAt.Equal(clock.Now()) {
+				t.Errorf("reopened at %v, want %v: the cooldown restarts", status.OpenedAt, clock.Now())
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpenAllowsOneTrial(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("connection reset")}
+	breaker, clock := newTestBreaker(provider, BreakerConfig{FailureThreshold: 1, Cooldown: time.Second})
+	call(breaker)
+	clock.Advance(time.Second)
+
+	provider.fail(nil)
+	provider.started = make(chan struct{})
+	provider.release = make(chan struct{})
+	trial := make(chan error)
+	go func() { trial <- call(breaker) }()
+	<-provider.started
+
+	// the trial is still in flight: everyone else is turned away
+	if state := breaker.Status().State; state != "half_open" {
+		t.Errorf("state during the trial = %s, want half_open", state)
+	}
+	if err := call(breaker); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call while half-open: err = %v, want ErrCircuitOpen", err)
+	}
+
+	close(provider.release)
+	if err := <-trial; err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if state := breaker.Status().State; state != "closed" {
+		t.Errorf("state after the trial = %s, want closed", state)
+	}
+	if provider.callCount() != 2 {
+		t.Errorf("upstream calls = %d, want 2", provider.callCount())
+	}
+}
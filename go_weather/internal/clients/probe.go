@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"goweather/internal/logger"
+)
+
+// ProbeConfig controls the background checks of unhealthy providers.
+type ProbeConfig struct {
+	Interval time.Duration // time between rounds; 0 disables probing
+	Location string        // queried on each probe
+}
+
+// Prober re-checks unhealthy providers in the background. Health only
+// changes when a call reaches the upstream, so without probes a provider
+// that failed while readiness was failing would stay unhealthy: the
+// orchestrator sends no traffic, and no traffic means no calls. Probes go
+// through the circuit breaker, so an open circuit is only tried once its
+// cooldown is over, as its half-open trial call.
+type Prober struct {
+	registry *Registry
+	config   ProbeConfig
+	logger   *logger.Logger
+}
+
+func NewProber(registry *Registry, cfg ProbeConfig) *Prober {
+	if cfg.Location == "" {
+		cfg.Location = "London"
+	}
+	return &Prober{
+		registry: registry,
+		config:   cfg,
+		logger:   logger.Get(),
+	}
+}
+
+// Run probes every Interval until ctx is done.
+func (p *Prober) Run(ctx context.Context) {
+	if p.config.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.ProbeUnhealthy(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ProbeUnhealthy calls each provider whose Health reports an error once and
+// returns how many are healthy afterwards. Healthy providers are left alone,
+// so probing costs nothing upstream while everything works.
+func (p *Prober) ProbeUnhealthy(ctx context.Context) int {
+	healthy := 0
+	for _, provider := range p.registry.Providers() {
+		before := provider.Health()
+		if before == nil {
+			healthy++
+			continue
+		}
+
+		_, err := provider.GetCurrent(ctx, p.config.Location)
+		after := provider.Health()
+		if after == nil {
+			healthy++
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			continue // still cooling down, nothing was sent
+		}
+		p.logger.Ctx(ctx).Info().
+			Str("component", "provider").
+			Str("action", "probe").
+			Str("provider", provider.Name()).
+			Bool("healthy", after == nil).
+			AnErr("unhealthy_reason", before).
+			AnErr("probe_error", err).
+			Msg("Unhealthy provider probed")
+	}
+	return healthy
+}
@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+// recordingProvider keeps Health like the real clients: the outcome of the
+// last call that reached the upstream.
+type recordingProvider struct {
+	fakeProvider
+	health healthState
+}
+
+func (p *recordingProvider) GetCurrent(ctx context.Context, location string) (*types.Conditions, error) {
+	conditions, err := p.fakeProvider.GetCurrent(ctx, location)
+	p.health.record(ctx, err)
+	return conditions, err
+}
+
+func (p *recordingProvider) Health() error { return p.health.Health() }
+
+func TestProbeRecoversUnhealthyProvider(t *testing.T) {
+	provider := &recordingProvider{fakeProvider: fakeProvider{err: &StatusError{StatusCode: http.StatusServiceUnavailable}}}
+	provider.GetCurrent(context.Background(), "istanbul")
+	prober := NewProber(NewRegistry(provider), ProbeConfig{Interval: time.Minute})
+
+	if healthy := prober.ProbeUnhealthy(context.Background()); healthy != 0 {
+		t.Fatalf("healthy = %d while the upstream still fails, want 0", healthy)
+	}
+	provider.fail(nil)
+	if healthy := prober.ProbeUnhealthy(context.Background()); healthy != 1 {
+		t.Fatalf("healthy = %d after the upstream recovered, want 1", healthy)
+	}
+	if provider.callCount() != 3 {
+		t.Errorf("upstream calls = %d, want 3", provider.callCount())
+	}
+
+	// healthy providers aren't called
+	prober.ProbeUnhealthy(context.Background())
+	if provider.callCount() != 3 {
+		t.Errorf("upstream calls = %d after probing a healthy provider, want 3", provider.callCount())
+	}
+}
+
+func TestProbeWaitsForBreakerCooldown(t *testing.T) {
+	provider := &recordingProvider{fakeProvider: fakeProvider{err: &StatusError{StatusCode: http.StatusBadGateway}}}
+	breaker, clock := newTestBreaker(provider, BreakerConfig{FailureThreshold: 1, Cooldown: 30 * time.Second})
+	breaker.GetCurrent(context.Background(), "istanbul")
+	prober := NewProber(NewRegistry(breaker), ProbeConfig{Interval: time.Minute})
+
+	provider.fail(nil)
+	if healthy := prober.ProbeUnhealthy(context.Background()); healthy != 0 || provider.callCount() != 1 {
+		t.Fatalf("probe during the cooldown: healthy = %d, calls = %d; want 0 and no new call", healthy, provider.callCount())
+	}
+
+	clock.Advance(30 * time.Second)
+	if healthy := prober.ProbeUnhealthy(context.Background()); healthy != 1 {
+		t.Fatalf("probe after the cooldown: healthy = %d, want 1", healthy)
+	}
+	if state := breaker.Status().State; state != "closed" {
+		t.Errorf("breaker state = %s after a successful probe, want closed", state)
+	}
+}
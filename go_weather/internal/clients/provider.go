@@ -0,0 +1,218 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"goweather/internal/config"
+	"goweather/pkg/types"
+)
+
+// WeatherProvider is implemented by every upstream weather source the
+// service can fan out to.
+type WeatherProvider interface {
+	// Name is the stable identifier used in config, logs and the database.
+	Name() string
+	// GetCurrent fetches the current conditions for a location.
+	GetCurrent(ctx context.Context, location string) (*types.Conditions, error)
+	// Health reports the outcome of the most recent upstream call.
+	Health() error
+}
+
+// ForecastProvider is implemented by providers that also serve daily forecasts.
+type ForecastProvider interface {
+	WeatherProvider
+	// GetForecast returns up to `days` daily forecasts starting today.
+	GetForecast(ctx context.Context, location string, days int) ([]types.ForecastDay, error)
+}
+
+// ProviderFactory builds a provider from the application config.
+type ProviderFactory func(cfg *config.Config) WeatherProvider
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = map[string]ProviderFactory{
+		"weatherapi": func(cfg *config.Config) WeatherProvider {
+			return NewWeatherAPIClient(cfg.WeatherAPIKey, cfg.APITimeout, RetryPolicyFromConfig(cfg))
+		},
+		"weatherstack": func(cfg *config.Config) WeatherProvider {
+			return NewWeatherStackClient(cfg.WeatherStackKey, cfg.APITimeout, RetryPolicyFromConfig(cfg))
+		},
+	}
+)
+
+// RegisterFactory makes a provider available by name to WEATHER_PROVIDERS.
+func RegisterFactory(name string, factory ProviderFactory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[strings.ToLower(name)] = factory
+}
+
+// Registry keeps the configured providers in registration order.
+type Registry struct {
+	mutex     sync.RWMutex
+	providers []WeatherProvider
+}
+
+func NewRegistry(providers ...WeatherProvider) *Registry {
+	return &Registry{providers: append([]WeatherProvider(nil), providers...)}
+}
+
+// NewRegistryFromConfig builds the providers listed in cfg.Providers.
+func NewRegistryFromConfig(cfg *config.Config) (*Registry, error) {
+	registry := NewRegistry()
+
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	for _, name := range cfg.Providers {
+		factory, ok := factories[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weather provider: %s", name)
+		}
+		provider := factory(cfg)
+		if cfg.BreakerFailureThreshold > 0 {
+			provider = NewCircuitBreaker(provider, BreakerConfig{
+				FailureThreshold: cfg.BreakerFailureThreshold,
+				Cooldown:         cfg.BreakerCooldown,
+				HalfOpenRequests: cfg.BreakerHalfOpenRequests,
+			})
+		}
+		if err := registry.Register(provider); err != nil {
+			return nil, err
+		}
+	}
+
+	if registry.Len() == 0 {
+		return nil, fmt.Errorf("no weather providers configured")
+	}
+	return registry, nil
+}
+
+// Register adds a provider; names must be unique.
+func (r *Registry) Register(provider WeatherProvider) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, p := range r.providers {
+		if p.Name() == provider.Name() {
+			return fmt.Errorf("weather provider already registered: %s", provider.Name())
+		}
+	}
+	r.providers = append(r.providers, provider)
+	return nil
+}
+
+// Get returns the provider registered under name.
+func (r *Registry) Get(name string) (WeatherProvider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, p := range r.providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Providers returns a snapshot of the registered providers.
+func (r *Registry) Providers() []WeatherProvider {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	providers := make([]WeatherProvider, len(r.providers))
+	copy(providers, r.providers)
+	return providers
+}
+
+// ForecastProviders returns the registered providers that serve forecasts,
+// looking through circuit breakers at the provider they wrap.
+func (r *Registry) ForecastProviders() []ForecastProvider {
+	var forecasters []ForecastProvider
+	for _, p := range r.Providers() {
+		if breaker, ok := p.(*CircuitBreaker); ok {
+			if _, ok := breaker.provider.(ForecastProvider); !ok {
+				continue
+			}
+		}
+		if forecaster, ok := p.(ForecastProvider); ok {
+			forecasters = append(forecasters, forecaster)
+		}
+	}
+	return forecasters
+}
+
+func (r *Registry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.providers)
+}
+
+// ProviderStatus is the status endpoint view of a single provider.
+type ProviderStatus struct {
+	Provider  string         `json:"provider"`
+	Healthy   bool           `json:"healthy"`
+	LastError string         `json:"last_error,omitempty"`
+	Breaker   *BreakerStatus `json:"circuit_breaker,omitempty"`
+}
+
+// Statuses reports health and, where wrapped, circuit breaker state.
+func (r *Registry) Statuses() []ProviderStatus {
+	providers := r.Providers()
+	statuses := make([]ProviderStatus, 0, len(providers))
+	for _, p := range providers {
+		status := ProviderStatus{Provider: p.Name(), Healthy: true}
+		if err := p.Health(); err != nil {
+			status.Healthy = false
+			status.LastError = err.Error()
+		}
+		if breaker, ok := p.(*CircuitBreaker); ok {
+			breakerStatus := breaker.Status()
+			status.Breaker = &breakerStatus
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// healthState records the last upstream outcome for Health().
+type healthState struct {
+	mutex   sync.RWMutex
+	lastErr error
+}
+
+// record keeps the outcome of a call with the circuit breaker's rules:
+// calls the caller abandoned are ignored, and answers that only say the
+// request was bad (4xx other than 429, e.g. an unknown location) count as
+// healthy, so one bogus query can't take the provider out of /readyz.
+func (h *healthState) record(ctx context.Context, err error) {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if !isBreakerFailure(err) {
+		err = nil
+	}
+	h.mutex.Lock()
+	h.lastErr = err
+	h.mutex.Unlock()
+}
+
+func (h *healthState) Health() error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.lastErr
+}
+
+// StatusError is returned when an upstream answers with a non-200 status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error (Status: %d): %s", e.StatusCode, e.Body)
+}
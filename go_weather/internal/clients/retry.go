@@ -0,0 +1,151 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goweather/internal/config"
+	"goweather/internal/logger"
+)
+
+// RetryPolicy controls how transient upstream failures are retried. All
+// attempts, including the waits between them, share the client's timeout
+// as one overall budget.
+type RetryPolicy struct {
+	MaxAttempts     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Jitter          float64 // fraction of each delay that is randomized, 0..1
+	RetryableStatus []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		BaseDelay:       200 * time.Millisecond,
+		MaxDelay:        2 * time.Second,
+		Jitter:          0.5,
+		RetryableStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// RetryPolicyFromConfig builds the policy from RETRY_* settings.
+func RetryPolicyFromConfig(cfg *config.Config) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     cfg.RetryMaxAttempts,
+		BaseDelay:       cfg.RetryBaseDelay,
+		MaxDelay:        cfg.RetryMaxDelay,
+		Jitter:          cfg.RetryJitter,
+		RetryableStatus: cfg.RetryStatusCodes,
+	}
+}
+
+func (p RetryPolicy) retryable(statusCode int) bool {
+	for _, code := range p.RetryableStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the exponential delay before the given retry (1-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		jitter := min(p.Jitter, 1)
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// doWithRetry sends a GET request until it succeeds, fails permanently or
+// the budget runs out, and returns the status and body of the last attempt.
+func doWithRetry(client *http.Client, req *http.Request, policy RetryPolicy, service, location string) (int, []byte, error) {
+	ctx := req.Context()
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	maxAttempts := max(policy.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		statusCode, header, body, err := doOnce(client, req.Clone(ctx))
+
+		var reason string
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			reason = err.Error()
+		case policy.retryable(statusCode):
+			reason = fmt.Sprintf("status %d", statusCode)
+			retryAfter = parseRetryAfter(header.Get("Retry-After"))
+		default:
+			return statusCode, body, nil
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return statusCode, body, err
+		}
+
+		delay := max(policy.backoff(attempt), retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return statusCode, body, err
+		}
+
+		logger.Ctx(ctx).APIRetry(service, location, attempt, delay, reason)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("reason", reason),
+			attribute.Int64("delay_ms", delay.Milliseconds()),
+		))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return statusCode, body, err
+		}
+	}
+}
+
+func doOnce(client *http.Client, req *http.Request) (int, http.Header, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("response read failed: %w", err)
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
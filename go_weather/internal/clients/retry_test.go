@@ -0,0 +1,210 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowth(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, delay := range want {
+		if got := policy.backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+
+	// a shift past the int64 range must still land on MaxDelay
+	if got := policy.backoff(80); got != time.Second {
+		t.Errorf("backoff(80) = %v, want MaxDelay", got)
+	}
+}
+
+func TestBackoffJitterBounds(t *testing.T) {
+	tests := []struct {
+		jitter float64
+		min    time.Duration
+	}{
+		{0.5, 200 * time.Millisecond},
+		{1, 0},
+		{3, 0}, // clamped to 1
+	}
+	for _, tt := range tests {
+		policy := RetryPolicy{BaseDelay: 400 * time.Millisecond, MaxDelay: time.Second, Jitter: tt.jitter}
+		for i := 0; i < 1000; i++ {
+			if got := policy.backoff(1); got < tt.min || got > 400*time.Millisecond {
+				t.Fatalf("jitter %v: backoff(1) = %v, want within [%v, 400ms]", tt.jitter, got, tt.min)
+			}
+		}
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	for status, want := range map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+		http.StatusInternalServerError: false,
+		http.StatusBadRequest:          false,
+		http.StatusUnauthorized:        false,
+		http.StatusNotFound:            false,
+		http.StatusOK:                  false,
+	} {
+		if got := policy.retryable(status); got != want {
+			t.Errorf("retryable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want within [%v, %v]", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+// statusServer answers with the given statuses in turn, repeating the last
+// one, and counts the requests it got.
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+		w.Write([]byte("body"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func fastPolicy(attempts int) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = attempts
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	return policy
+}
+
+func get(t *testing.T, client *http.Client, url string, policy RetryPolicy) (int, []byte, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doWithRetry(client, req, policy, "test", "istanbul")
+}
+
+func TestDoWithRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		want     int
+		requests int32
+	}{
+		{"success first time", []int{200}, 3, 200, 1},
+		{"retried until success", []int{503, 502, 200}, 3, 200, 3},
+		{"attempts run out", []int{503}, 3, 503, 3},
+		{"429 is retried", []int{429, 200}, 3, 200, 2},
+		{"500 is not retried", []int{500, 200}, 3, 500, 1},
+		{"404 is not retried", []int{404, 200}, 3, 404, 1},
+		{"single attempt", []int{503, 200}, 1, 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := statusServer(t, nil, tt.statuses...)
+			status, body, err := get(t, &http.Client{Timeout: 5 * time.Second}, server.URL, fastPolicy(tt.attempts))
+			if err != nil {
+				t.Fatalf("doWithRetry: %v", err)
+			}
+			if status != tt.want || string(body) != "body" {
+				t.Errorf("status = %d, body %q; want %d", status, body, tt.want)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	_, _, err := get(t, &http.Client{Timeout: 5 * time.Second}, url, fastPolicy(2))
+	if err == nil {
+		t.Fatal("doWithRetry against a closed server: err = nil")
+	}
+}
+
+func TestDoWithRetryHonoursRetryAfter(t *testing.T) {
+	server, requests := statusServer(t, http.Header{"Retry-After": {"1"}}, 503, 200)
+
+	start := time.Now()
+	status, _, err := get(t, &http.Client{Timeout: 5 * time.Second}, server.URL, fastPolicy(2))
+	if err != nil || status != 200 {
+		t.Fatalf("doWithRetry = %d, %v; want 200", status, err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+}
+
+func TestDoWithRetryStopsAtTimeoutBudget(t *testing.T) {
+	// the upstream asks for a wait longer than the whole client timeout
+	server, requests := statusServer(t, http.Header{"Retry-After": {"10"}}, 503, 200)
+
+	start := time.Now()
+	status, _, err := get(t, &http.Client{Timeout: 200 * time.Millisecond}, server.URL, fastPolicy(5))
+	if err != nil || status != 503 {
+		t.Fatalf("doWithRetry = %d, %v; want the 503 of the only attempt", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("gave up after %v, want right away instead of sleeping into the deadline", elapsed)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want 1", requests.Load())
+	}
+}
+
+func TestDoWithRetrySharesTimeoutBudget(t *testing.T) {
+	server, requests := statusServer(t, nil, 503)
+	policy := fastPolicy(100)
+	policy.BaseDelay = 40 * time.Millisecond
+	policy.MaxDelay = 40 * time.Millisecond
+	policy.Jitter = 0
+
+	start := time.Now()
+	get(t, &http.Client{Timeout: 300 * time.Millisecond}, server.URL, policy)
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("retries took %v, want them to stop within the 300ms budget", elapsed)
+	}
+	if got := requests.Load(); got < 2 || got > 8 {
+		t.Errorf("requests = %d, want a few retries inside the budget, not all 100 attempts", got)
+	}
+}
@@ -1,10 +1,14 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 	
 	"goweather/internal/logger"
@@ -16,60 +20,60 @@ type WeatherAPIClient struct {
 	APIKey string
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
 	logger  *logger.Logger
+	healthState
 }
 
 // NewWeatherAPIClient 
-func NewWeatherAPIClient(apiKey string, timeout time.Duration) *WeatherAPIClient {
+func NewWeatherAPIClient(apiKey string, timeout time.Duration, retry RetryPolicy) *WeatherAPIClient {
 	return &WeatherAPIClient{
 		APIKey:  apiKey,
 		BaseURL: "http://api.weatherapi.com/v1/forecast.json",
 		Client: &http.Client{
 			Timeout: timeout, 
 		},
+		Retry:  retry,
 		logger: logger.Get(),
 	}
 }
 
 // GetWeather 
-func (c *WeatherAPIClient) GetWeather(location string) (*types.WeatherAPIResponse, error) {
+func (c *WeatherAPIClient) GetWeather(ctx context.Context, location string) (*types.WeatherAPIResponse, error) {
+	return c.fetch(ctx, location, 1)
+}
+
+// fetch calls forecast.json, which carries current conditions and `days` daily forecasts
+func (c *WeatherAPIClient) fetch(ctx context.Context, location string, days int) (*types.WeatherAPIResponse, error) {
 	startTime := time.Now()
-	url := fmt.Sprintf("%s?key=%s&q=%s&days=1&aqi=no&alerts=no", 
-		c.BaseURL, c.APIKey, location)
+	requestURL := fmt.Sprintf("%s?key=%s&q=%s&days=%d&aqi=no&alerts=no", 
+		c.BaseURL, url.QueryEscape(c.APIKey), url.QueryEscape(location), days)
 
-	c.logger.APIRequest("weatherapi", location, url).Msg("API request started")
+	c.logger.Ctx(ctx).APIRequest("weatherapi", location, requestURL).Msg("API request started")
 	
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
-		c.logger.APIError("weatherapi", location, err, time.Since(startTime))
+		c.logger.Ctx(ctx).APIError("weatherapi", location, err, time.Since(startTime))
 		return nil, fmt.Errorf("HTTP request creation failed: %v", err)
 	}
 
 	// send req
-	resp, err := c.Client.Do(req)
+	statusCode, body, err := doWithRetry(c.Client, req, c.Retry, "weatherapi", location)
 	responseTime := time.Since(startTime)
 	
 	if err != nil {
-		c.logger.APIError("weatherapi", location, err, responseTime)
-		return nil, fmt.Errorf("HTTP request failed: %v", err)
+		c.logger.Ctx(ctx).APIError("weatherapi", location, err, responseTime)
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	// Status code check
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		apiErr := fmt.Errorf("API error (Status: %d): %s", resp.StatusCode, string(body))
-		c.logger.APIError("weatherapi", location, apiErr, responseTime)
+	if statusCode != http.StatusOK {
+		apiErr := &StatusError{StatusCode: statusCode, Body: string(body)}
+		c.logger.Ctx(ctx).APIError("weatherapi", location, apiErr, responseTime)
 		return nil, apiErr
 	}
 	
-	c.logger.APIResponse("weatherapi", location, resp.StatusCode, responseTime)
-
-	// Response body read
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Response read failed: %v", err)
-	}
+	c.logger.Ctx(ctx).APIResponse("weatherapi", location, statusCode, responseTime)
 
 	// JSON parse
 	var weatherResp types.WeatherAPIResponse
@@ -81,11 +85,64 @@ func (c *WeatherAPIClient) GetWeather(location string) (*types.WeatherAPIRespons
 }
 
 // GetTemperature 
-func (c *WeatherAPIClient) GetTemperature(location string) (float64, error) {
-	weather, err := c.GetWeather(location)
+func (c *WeatherAPIClient) GetTemperature(ctx context.Context, location string) (float64, error) {
+	weather, err := c.GetWeather(ctx, location)
 	if err != nil {
 		return 0, err
 	}
 
 	return weather.Current.TempC, nil
 }
+
+// Name 
+func (c *WeatherAPIClient) Name() string {
+	return "weatherapi"
+}
+
+// GetCurrent 
+func (c *WeatherAPIClient) GetCurrent(ctx context.Context, location string) (*types.Conditions, error) {
+	weather, err := c.GetWeather(ctx, location)
+	c.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	current := weather.Current
+	conditions := &types.Conditions{
+		Temperature:   current.TempC,
+		FeelsLike:     current.FeelsLikeC,
+		Humidity:      current.Humidity,
+		WindSpeed:     current.WindKph,
+		WindDirection: current.WindDegree,
+		Pressure:      current.PressureMb,
+		ConditionText: current.Condition.Text,
+	}
+	if code, err := strconv.Atoi(strings.TrimSuffix(path.Base(current.Condition.Icon), ".png")); err == nil {
+		conditions.ConditionCode = &code
+	}
+	if current.LastUpdatedEpoch > 0 {
+		observedAt := time.Unix(current.LastUpdatedEpoch, 0).UTC()
+		conditions.ObservedAt = &observedAt
+	}
+	return conditions, nil
+}
+
+// GetForecast returns up to `days` daily forecasts starting today
+func (c *WeatherAPIClient) GetForecast(ctx context.Context, location string, days int) ([]types.ForecastDay, error) {
+	weather, err := c.fetch(ctx, location, days)
+	c.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := make([]types.ForecastDay, 0, len(weather.Forecast.ForecastDay))
+	for _, day := range weather.Forecast.ForecastDay {
+		forecast = append(forecast, types.ForecastDay{
+			Date:    day.Date,
+			MinTemp: day.Day.MinTempC,
+			MaxTemp: day.Day.MaxTempC,
+			AvgTemp: day.Day.AvgTempC,
+		})
+	}
+	return forecast, nil
+}
@@ -1,10 +1,11 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"goweather/internal/logger"
@@ -16,72 +17,143 @@ type WeatherStackClient struct {
 	APIKey  string
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
 	logger  *logger.Logger
+	healthState
 }
 
-func NewWeatherStackClient(apiKey string, timeout time.Duration) *WeatherStackClient {
+func NewWeatherStackClient(apiKey string, timeout time.Duration, retry RetryPolicy) *WeatherStackClient {
 	return &WeatherStackClient{
 		APIKey:  apiKey,
 		BaseURL: "http://api.weatherstack.com/current", //HTTP
 		Client: &http.Client{
 			Timeout: timeout, 
 		},
+		Retry:  retry,
 		logger: logger.Get(),
 	}
 }
 
 
-func (c *WeatherStackClient) GetWeather(location string) (*types.WeatherStackResponse, error) {
+func (c *WeatherStackClient) GetWeather(ctx context.Context, location string) (*types.WeatherStackResponse, error) {
 	startTime := time.Now()
-	url := fmt.Sprintf("%s?access_key=%s&query=%s", 
-		c.BaseURL, c.APIKey, location)
+	// units=m pins metric so the account default can't change what gets averaged;
+	// /weather converts to the caller's units afterwards
+	requestURL := fmt.Sprintf("%s?access_key=%s&query=%s&units=m", 
+		c.BaseURL, url.QueryEscape(c.APIKey), url.QueryEscape(location))
 
-	c.logger.APIRequest("weatherstack", location, url).Msg("API request started")
+	c.logger.Ctx(ctx).APIRequest("weatherstack", location, requestURL).Msg("API request started")
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
-		c.logger.APIError("weatherstack", location, err, time.Since(startTime))
+		c.logger.Ctx(ctx).APIError("weatherstack", location, err, time.Since(startTime))
 		return nil, fmt.Errorf("HTTP isteği oluşturulamadı: %v", err)
 	}
 
-	resp, err := c.Client.Do(req)
+	statusCode, body, err := doWithRetry(c.Client, req, c.Retry, "weatherstack", location)
 	responseTime := time.Since(startTime)
 	
 	if err != nil {
-		c.logger.APIError("weatherstack", location, err, responseTime)
-		return nil, fmt.Errorf("HTTP isteği başarısız: %v", err)
+		c.logger.Ctx(ctx).APIError("weatherstack", location, err, responseTime)
+		return nil, fmt.Errorf("HTTP isteği başarısız: %w", err)
 	}
-	defer resp.Body.Close()
 
-	//Status code check
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		apiErr := fmt.Errorf("API hatası (Status: %d): %s", resp.StatusCode, string(body))
-		c.logger.APIError("weatherstack", location, apiErr, responseTime)
+	// Status code check
+	if statusCode != http.StatusOK {
+		apiErr := &StatusError{StatusCode: statusCode, Body: string(body)}
+		c.logger.Ctx(ctx).APIError("weatherstack", location, apiErr, responseTime)
 		return nil, apiErr
 	}
 	
-	c.logger.APIResponse("weatherstack", location, resp.StatusCode, responseTime)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Response okunamadı: %v", err)
-	}
+	c.logger.Ctx(ctx).APIResponse("weatherstack", location, statusCode, responseTime)
 
 	var weatherResp types.WeatherStackResponse
 	if err := json.Unmarshal(body, &weatherResp); err != nil {
 		return nil, fmt.Errorf("JSON parse hatası: %v", err)
 	}
 
+	// Weatherstack hataları HTTP 200 ile döner; 0°C okuma gibi ortalamaya girmesin
+	if weatherResp.Error != nil || (weatherResp.Success != nil && !*weatherResp.Success) {
+		apiErr := &StatusError{StatusCode: weatherStackStatus(weatherResp.Error), Body: string(body)}
+		c.logger.Ctx(ctx).APIError("weatherstack", location, apiErr, responseTime)
+		return nil, apiErr
+	}
+
 	return &weatherResp, nil
 }
 
+// weatherStackStatus maps a Weatherstack error code to the HTTP status it
+// stands for, so the breaker, retries and health treat it like any other
+// upstream answer: bad requests and unknown locations don't count against
+// the provider, exhausted quotas and unknown failures do.
+func weatherStackStatus(apiErr *types.WeatherStackError) int {
+	if apiErr == nil {
+		return http.StatusBadGateway
+	}
+	switch apiErr.Code {
+	case 101, 102: // invalid_access_key, inactive_user
+		return http.StatusUnauthorized
+	case 103, 105: // invalid_api_function, function_access_restricted
+		return http.StatusForbidden
+	case 104: // usage_limit_reached
+		return http.StatusTooManyRequests
+	case 404: // 404_not_found
+		return http.StatusNotFound
+	case 601, 615: // missing_query, request_failed (no matching location)
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
 // Get temperature 
-func (c *WeatherStackClient) GetTemperature(location string) (float64, error) {
-	weather, err := c.GetWeather(location)
+func (c *WeatherStackClient) GetTemperature(ctx context.Context, location string) (float64, error) {
+	weather, err := c.GetWeather(ctx, location)
 	if err != nil {
 		return 0, err
 	}
 
 	return weather.Current.Temperature, nil
 }
+
+func (c *WeatherStackClient) Name() string {
+	return "weatherstack"
+}
+
+// Get current conditions 
+func (c *WeatherStackClient) GetCurrent(ctx context.Context, location string) (*types.Conditions, error) {
+	weather, err := c.GetWeather(ctx, location)
+	c.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	current := weather.Current
+	conditions := &types.Conditions{
+		Temperature:   current.Temperature,
+		FeelsLike:     current.FeelsLike,
+		Humidity:      current.Humidity,
+		WindSpeed:     current.WindSpeed,
+		WindDirection: current.WindDegree,
+		Pressure:      current.Pressure,
+		ConditionCode: current.WeatherCode,
+		ObservedAt:    parseObservationTime(current.ObservationTime, time.Now().UTC()),
+	}
+	if len(current.WeatherDescriptions) > 0 {
+		conditions.ConditionText = current.WeatherDescriptions[0]
+	}
+	return conditions, nil
+}
+
+// parseObservationTime turns Weatherstack's UTC "03:04 PM" into the most recent such instant
+func parseObservationTime(value string, now time.Time) *time.Time {
+	clock, err := time.Parse("03:04 PM", value)
+	if err != nil {
+		return nil
+	}
+	observedAt := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+	if observedAt.After(now) {
+		observedAt = observedAt.AddDate(0, 0, -1)
+	}
+	return &observedAt
+}
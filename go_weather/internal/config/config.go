@@ -4,23 +4,96 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// AggregationOverride replaces the global aggregation settings for one
+// location; zero fields keep the global value.
+type AggregationOverride struct {
+	Strategy    string
+	MaxRequests int
+	WaitTime    time.Duration
+}
+
 type Config struct {
 	WeatherAPIKey  string
 	WeatherStackKey string
+	Providers      []string
+	ProviderQuorum int
+	
+	DatabaseDriver string
+	DatabasePath   string
+	DatabaseURL    string
+	
+	DBQueueSize     int
+	DBBatchSize     int
+	DBFlushInterval time.Duration
+	DBDropPolicy    string
+	DBBlockTimeout  time.Duration
+	
+	ServerPort      string
+	DebugMode       bool
+	AdminToken      string
+	ShutdownTimeout time.Duration
 	
-	DatabasePath string
+	ReadyQueueThreshold float64
 	
-	ServerPort string
-	DebugMode  bool
+	MetricsEnabled   bool
+	MetricsLocations []string
+	
+	RateLimitEnabled    bool
+	RateLimitRate       float64
+	RateLimitBurst      int
+	RateLimitIdleTTL    time.Duration
+	RateLimitTrustProxy bool
+	
+	AuthRequired bool
+	AuthCacheTTL time.Duration
+	
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingServiceName string
+	TracingSampleRatio float64
 	
 	MaxRequests int
 	WaitTime    time.Duration
+	
+	AggregationStrategy    string
+	AggregationQuietPeriod time.Duration
+	AggregationMinWait     time.Duration
+	AggregationOverrides   map[string]AggregationOverride
+	
+	GroupIdleTTL         time.Duration
+	GroupJanitorInterval time.Duration
+	MaxGroups            int
+	GroupOverflow        string
+	
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+	
+	ForecastMaxDays   int
+	BatchMaxLocations int
+	
+	LocationAliases     map[string]string
+	CoordinatePrecision int
+	
 	APITimeout time.Duration
+	
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	RetryJitter      float64
+	RetryStatusCodes []int
+	
+	BreakerFailureThreshold int
+	BreakerCooldown         time.Duration
+	BreakerHalfOpenRequests int
+	
+	ProviderProbeInterval time.Duration
+	ProviderProbeLocation string
 }
 
 func LoadConfig() *Config {
@@ -32,16 +105,79 @@ func LoadConfig() *Config {
 	config := &Config{
 		WeatherAPIKey:   getEnv("WEATHER_API_KEY", "b417cbe563c444f98a0124504252409"),
 		WeatherStackKey: getEnv("WEATHER_STACK_KEY", "e8919ef8c0246a634fb92cf4567c3681"),
+		Providers:       getEnvAsList("WEATHER_PROVIDERS", "weatherapi,weatherstack"),
+		ProviderQuorum:  getEnvAsInt("PROVIDER_QUORUM", 0),
+		
+		DatabaseDriver: getEnv("DATABASE_DRIVER", "sqlite"),
+		DatabasePath:   getEnv("DATABASE_PATH", "weather.sqlite"),
+		DatabaseURL:    getEnv("DATABASE_URL", ""),
+		
+		DBQueueSize:     getEnvAsInt("DB_QUEUE_SIZE", 1000),
+		DBBatchSize:     getEnvAsInt("DB_BATCH_SIZE", 50),
+		DBFlushInterval: getEnvAsDuration("DB_FLUSH_INTERVAL", "500ms"),
+		DBDropPolicy:    getEnv("DB_DROP_POLICY", "drop"),
+		DBBlockTimeout:  getEnvAsDuration("DB_BLOCK_TIMEOUT", "50ms"),
 		
-		DatabasePath: getEnv("DATABASE_PATH", "weather.sqlite"),
+		ServerPort:      getEnv("SERVER_PORT", "8000"),
+		DebugMode:       getEnvAsBool("DEBUG_MODE", false),
+		AdminToken:      getEnv("ADMIN_TOKEN", ""),
+		ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", "15s"),
 		
-		ServerPort: getEnv("SERVER_PORT", "8000"),
-		DebugMode:  getEnvAsBool("DEBUG_MODE", false),
+		ReadyQueueThreshold: getEnvAsFloat("READY_QUEUE_THRESHOLD", 0.9),
+		
+		MetricsEnabled:   getEnvAsBool("METRICS_ENABLED", true),
+		MetricsLocations: getEnvAsList("METRICS_LOCATIONS", "Istanbul,Ankara,Izmir"),
+		
+		RateLimitEnabled:    getEnvAsBool("RATE_LIMIT_ENABLED", false),
+		RateLimitRate:       getEnvAsFloat("RATE_LIMIT_RATE", 5),
+		RateLimitBurst:      getEnvAsInt("RATE_LIMIT_BURST", 20),
+		RateLimitIdleTTL:    getEnvAsDuration("RATE_LIMIT_IDLE_TTL", "10m"),
+		RateLimitTrustProxy: getEnvAsBool("RATE_LIMIT_TRUST_PROXY", false),
+		
+		AuthRequired: getEnvAsBool("AUTH_REQUIRED", false),
+		AuthCacheTTL: getEnvAsDuration("AUTH_CACHE_TTL", "1m"),
+		
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingEndpoint:    getEnv("TRACING_OTLP_ENDPOINT", "http://localhost:4318"),
+		TracingServiceName: getEnv("TRACING_SERVICE_NAME", "goweather"),
+		TracingSampleRatio: getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
 		
 		MaxRequests: getEnvAsInt("MAX_REQUESTS", 10),
 		WaitTime:    getEnvAsDuration("WAIT_TIME", "5s"),
 		
+		AggregationStrategy:    getEnv("AGGREGATION_STRATEGY", "fixed"),
+		AggregationQuietPeriod: getEnvAsDuration("AGGREGATION_QUIET_PERIOD", "1s"),
+		AggregationMinWait:     getEnvAsDuration("AGGREGATION_MIN_WAIT", "500ms"),
+		AggregationOverrides:   getEnvAsOverrides("AGGREGATION_OVERRIDES", ""),
+		
+		GroupIdleTTL:         getEnvAsDuration("GROUP_IDLE_TTL", "5m"),
+		GroupJanitorInterval: getEnvAsDuration("GROUP_JANITOR_INTERVAL", "1m"),
+		MaxGroups:            getEnvAsInt("MAX_GROUPS", 10000),
+		GroupOverflow:        getEnv("GROUP_OVERFLOW", "evict"),
+		
+		CacheTTL:        getEnvAsDuration("CACHE_TTL", "0"),
+		CacheMaxEntries: getEnvAsInt("CACHE_MAX_ENTRIES", 1000),
+		
+		ForecastMaxDays:   getEnvAsInt("FORECAST_MAX_DAYS", 3),
+		BatchMaxLocations: getEnvAsInt("BATCH_MAX_LOCATIONS", 500),
+		
+		LocationAliases:     getEnvAsMap("LOCATION_ALIASES", ""),
+		CoordinatePrecision: getEnvAsInt("COORDINATE_PRECISION", 2),
+		
 		APITimeout: getEnvAsDuration("API_TIMEOUT", "10s"),
+		
+		RetryMaxAttempts: getEnvAsInt("RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:   getEnvAsDuration("RETRY_BASE_DELAY", "200ms"),
+		RetryMaxDelay:    getEnvAsDuration("RETRY_MAX_DELAY", "2s"),
+		RetryJitter:      getEnvAsFloat("RETRY_JITTER", 0.5),
+		RetryStatusCodes: getEnvAsIntList("RETRY_STATUS_CODES", "429,502,503,504"),
+		
+		BreakerFailureThreshold: getEnvAsInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCooldown:         getEnvAsDuration("BREAKER_COOLDOWN", "30s"),
+		BreakerHalfOpenRequests: getEnvAsInt("BREAKER_HALF_OPEN_REQUESTS", 1),
+		
+		ProviderProbeInterval: getEnvAsDuration("PROVIDER_PROBE_INTERVAL", "30s"),
+		ProviderProbeLocation: getEnv("PROVIDER_PROBE_LOCATION", "London"),
 	}
 	
 	return config
@@ -63,6 +199,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue string) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -85,3 +230,66 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string, defaultValue string) []string {
+	value := getEnv(key, defaultValue)
+	
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvAsIntList(key string, defaultValue string) []int {
+	var list []int
+	for _, item := range getEnvAsList(key, defaultValue) {
+		if intValue, err := strconv.Atoi(item); err == nil {
+			list = append(list, intValue)
+		}
+	}
+	return list
+}
+
+// getEnvAsMap parses "key=value" pairs separated by commas
+func getEnvAsMap(key string, defaultValue string) map[string]string {
+	values := make(map[string]string)
+	for _, item := range getEnvAsList(key, defaultValue) {
+		if k, v, ok := strings.Cut(item, "="); ok {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+// getEnvAsOverrides parses "Location=max:50;wait:2s;strategy:debounce"
+// entries separated by commas; unparsable settings are skipped
+func getEnvAsOverrides(key string, defaultValue string) map[string]AggregationOverride {
+	overrides := make(map[string]AggregationOverride)
+	for location, settings := range getEnvAsMap(key, defaultValue) {
+		var override AggregationOverride
+		for _, setting := range strings.Split(settings, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(setting), ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(name) {
+			case "strategy":
+				override.Strategy = value
+			case "max":
+				if intValue, err := strconv.Atoi(value); err == nil {
+					override.MaxRequests = intValue
+				}
+			case "wait":
+				if duration, err := time.ParseDuration(value); err == nil {
+					override.WaitTime = duration
+				}
+			}
+		}
+		overrides[location] = override
+	}
+	return overrides
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+// Sort orders accepted by QueryHistory; ties are broken by id.
+const (
+	HistorySortCreatedAt    = "created_at"
+	HistorySortRequestCount = "request_count"
+)
+
+// ErrInvalidCursor is returned for a cursor that wasn't produced by
+// QueryHistory with the same sort order.
+var ErrInvalidCursor = errors.New("invalid history cursor")
+
+// timestampLayout is how SQLite's CURRENT_TIMESTAMP stores created_at (UTC)
+const timestampLayout = "2006-01-02 15:04:05"
+
+// rowTemperature averages the provider temperatures stored for a row
+const rowTemperature = `(COALESCE(service_1_temperature, 0) + COALESCE(service_2_temperature, 0)) /
+	NULLIF(CASE WHEN service_1_temperature IS NULL THEN 0 ELSE 1 END +
+	       CASE WHEN service_2_temperature IS NULL THEN 0 ELSE 1 END, 0)`
+
+// HistoryFilter selects weather_queries rows. Zero fields don't filter.
+type HistoryFilter struct {
+	Location string    // canonical location key
+	TenantID string    // only fetches that served this tenant
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+}
+
+// HistoryQuery is one page request over the rows matching HistoryFilter.
+type HistoryQuery struct {
+	HistoryFilter
+	Sort       string
+	Descending bool
+	Limit      int
+	Cursor     string // NextCursor of the previous page, empty for the first
+}
+
+// historyCursor is the keyset position after the last row of a page
+type historyCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	ID         int    `json:"i"`
+}
+
+func (f HistoryFilter) conditions() ([]string, []any) {
+	var conditions []string
+	var args []any
+	if f.Location != "" {
+		conditions = append(conditions, "location = ?")
+		args = append(args, f.Location)
+	}
+	if f.TenantID != "" {
+		conditions = append(conditions, "id IN (SELECT query_id FROM weather_query_tenants WHERE tenant_id = ?)")
+		args = append(args, f.TenantID)
+	}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.From.UTC().Format(timestampLayout))
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, f.To.UTC().Format(timestampLayout))
+	}
+	return conditions, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// QueryHistory returns one page of rows in the requested order. Pages are
+// keyset-paginated on (sort column, id), so rows written while a client
+// pages through never shift or repeat entries.
+func (d *Database) QueryHistory(ctx context.Context, q HistoryQuery) (*types.HistoryPage, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	conditions, args := q.conditions()
+	if q.Cursor != "" {
+		value, id, err := q.position()
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if q.Descending {
+			op = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", q.Sort, op))
+		args = append(args, value, value, id)
+	}
+
+	direction := "ASC"
+	if q.Descending {
+		direction = "DESC"
+	}
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM weather_queries
+	%s
+	ORDER BY %s %s, id %s
+	LIMIT ?`, weatherQueryColumns, whereClause(conditions), q.Sort, direction, direction)
+	args = append(args, q.Limit+1)
+
+	rows, err := d.db.QueryContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("history get failed: %v", err)
+	}
+	defer rows.Close()
+
+	page := &types.HistoryPage{Items: make([]types.WeatherQuery, 0, q.Limit)}
+	for rows.Next() {
+		row, err := scanWeatherQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("history read failed: %v", err)
+		}
+		page.Items = append(page.Items, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("history read failed: %v", err)
+	}
+
+	if len(page.Items) > q.Limit {
+		page.Items = page.Items[:q.Limit]
+		page.NextCursor = q.nextCursor(page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
+
+func (q HistoryQuery) validate() error {
+	if q.Sort != HistorySortCreatedAt && q.Sort != HistorySortRequestCount {
+		return fmt.Errorf("unknown history sort: %s", q.Sort)
+	}
+	if q.Limit <= 0 {
+		return fmt.Errorf("history limit must be positive")
+	}
+	return nil
+}
+
+// position decodes q.Cursor into the sort value and id the page continues
+// after. The value is a created_at string or a request count.
+func (q HistoryQuery) position() (any, int, error) {
+	cursor, err := decodeCursor(q.Cursor)
+	if err != nil || cursor.Sort != q.Sort || cursor.Descending != q.Descending {
+		return nil, 0, ErrInvalidCursor
+	}
+	if q.Sort == HistorySortRequestCount {
+		count, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return count, cursor.ID, nil
+	}
+	return cursor.Value, cursor.ID, nil
+}
+
+// nextCursor is the cursor of the page that follows last
+func (q HistoryQuery) nextCursor(last types.WeatherQuery) string {
+	cursor := historyCursor{Sort: q.Sort, Descending: q.Descending, ID: last.ID}
+	if q.Sort == HistorySortRequestCount {
+		cursor.Value = strconv.Itoa(last.RequestCount)
+	} else {
+		cursor.Value = last.CreatedAt.UTC().Format(timestampLayout)
+	}
+	return encodeCursor(cursor)
+}
+
+// HistoryStats aggregates the rows matching filter. Temperatures are the
+// per-row provider average; they are nil when no row has one.
+func (d *Database) HistoryStats(ctx context.Context, filter HistoryFilter) (*types.HistoryStats, error) {
+	conditions, args := filter.conditions()
+	query := fmt.Sprintf(`
+	SELECT COUNT(*), COALESCE(SUM(request_count), 0), MIN(temperature), MAX(temperature), AVG(temperature)
+	FROM (
+		SELECT request_count, %s AS temperature
+		FROM weather_queries
+		%s
+	) AS matched`, rowTemperature, whereClause(conditions))
+
+	stats := &types.HistoryStats{Location: filter.Location}
+	var minTemp, maxTemp, avgTemp sql.NullFloat64
+	err := d.db.QueryRowContext(ctx, d.rebind(query), args...).Scan(&stats.Fetches, &stats.TotalRequests, &minTemp, &maxTemp, &avgTemp)
+	if err != nil {
+		return nil, fmt.Errorf("history stats failed: %v", err)
+	}
+
+	if stats.Fetches > 0 {
+		stats.AvgBatchSize = float64(stats.TotalRequests) / float64(stats.Fetches)
+	}
+	stats.MinTemp = nullableFloat(minTemp)
+	stats.MaxTemp = nullableFloat(maxTemp)
+	stats.AvgTemp = nullableFloat(avgTemp)
+	if !filter.From.IsZero() {
+		stats.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		stats.To = &filter.To
+	}
+	return stats, nil
+}
+
+func nullableFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+func encodeCursor(cursor historyCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (historyCursor, error) {
+	var cursor historyCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+// DriverMemory is what MemoryStore reports as its driver
+const DriverMemory = "memory"
+
+// MemoryStore is a Store that keeps rows in memory, for tests that need
+// the service without a database file or server. created_at has the
+// one-second resolution of the SQL stores so paging behaves the same.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	rows   []types.WeatherQuery
+	nextID int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+func (s *MemoryStore) SaveWeatherQueries(ctx context.Context, queries []*types.WeatherQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, query := range queries {
+		query.ID = s.nextID
+		s.nextID++
+		row := *query
+		row.Tenants = slices.Clone(query.Tenants)
+		if row.CreatedAt.IsZero() {
+			row.CreatedAt = now
+		}
+		s.rows = append(s.rows, row)
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetWeatherQueries(ctx context.Context) ([]types.WeatherQuery, error) {
+	rows := s.matching(HistoryFilter{})
+	slices.SortFunc(rows, func(a, b types.WeatherQuery) int {
+		return -compareHistory(historyKey(HistorySortCreatedAt, a), a.ID, historyKey(HistorySortCreatedAt, b), b.ID)
+	})
+	return rows, nil
+}
+
+func (s *MemoryStore) QueryHistory(ctx context.Context, q HistoryQuery) (*types.HistoryPage, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	direction := 1
+	if q.Descending {
+		direction = -1
+	}
+
+	rows := s.matching(q.HistoryFilter)
+	if q.Cursor != "" {
+		value, id, err := q.position()
+		if err != nil {
+			return nil, err
+		}
+		rows = slices.DeleteFunc(rows, func(row types.WeatherQuery) bool {
+			return direction*compareHistory(historyKey(q.Sort, row), row.ID, value, id) <= 0
+		})
+	}
+	slices.SortFunc(rows, func(a, b types.WeatherQuery) int {
+		return direction * compareHistory(historyKey(q.Sort, a), a.ID, historyKey(q.Sort, b), b.ID)
+	})
+
+	if rows == nil {
+		rows = []types.WeatherQuery{}
+	}
+	page := &types.HistoryPage{Items: rows}
+	if len(rows) > q.Limit {
+		page.Items = rows[:q.Limit]
+		page.NextCursor = q.nextCursor(page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) HistoryStats(ctx context.Context, filter HistoryFilter) (*types.HistoryStats, error) {
+	stats := &types.HistoryStats{Location: filter.Location}
+	var temperatures []float64
+	for _, row := range s.matching(filter) {
+		stats.Fetches++
+		stats.TotalRequests += row.RequestCount
+		var sum float64
+		var count int
+		for _, temperature := range []*float64{row.Service1Temp, row.Service2Temp} {
+			if temperature != nil {
+				sum += *temperature
+				count++
+			}
+		}
+		if count > 0 {
+			temperatures = append(temperatures, sum/float64(count))
+		}
+	}
+
+	if stats.Fetches > 0 {
+		stats.AvgBatchSize = float64(stats.TotalRequests) / float64(stats.Fetches)
+	}
+	if len(temperatures) > 0 {
+		minTemp, maxTemp := slices.Min(temperatures), slices.Max(temperatures)
+		var sum float64
+		for _, temperature := range temperatures {
+			sum += temperature
+		}
+		avgTemp := sum / float64(len(temperatures))
+		stats.MinTemp, stats.MaxTemp, stats.AvgTemp = &minTemp, &maxTemp, &avgTemp
+	}
+	if !filter.From.IsZero() {
+		stats.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		stats.To = &filter.To
+	}
+	return stats, nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Driver() string {
+	return DriverMemory
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// matching copies the rows that pass filter, with the same bounds as the
+// SQL conditions: From inclusive, To exclusive.
+func (s *MemoryStore) matching(filter HistoryFilter) []types.WeatherQuery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from, to := filter.From.UTC().Format(timestampLayout), filter.To.UTC().Format(timestampLayout)
+	var rows []types.WeatherQuery
+	for _, row := range s.rows {
+		createdAt := historyKey(HistorySortCreatedAt, row).(string)
+		switch {
+		case filter.Location != "" && row.Location != filter.Location:
+		case filter.TenantID != "" && !slices.Contains(row.Tenants, filter.TenantID):
+		case !filter.From.IsZero() && createdAt < from:
+		case !filter.To.IsZero() && createdAt >= to:
+		default:
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// historyKey is the value row is ordered by, in the form position returns
+func historyKey(sort string, row types.WeatherQuery) any {
+	if sort == HistorySortRequestCount {
+		return row.RequestCount
+	}
+	return row.CreatedAt.UTC().Format(timestampLayout)
+}
+
+// compareHistory orders two (key, id) positions ascending
+func compareHistory(key any, id int, otherKey any, otherID int) int {
+	var order int
+	switch key := key.(type) {
+	case int:
+		order = cmp.Compare(key, otherKey.(int))
+	case string:
+		order = cmp.Compare(key, otherKey.(string))
+	}
+	if order != 0 {
+		return order
+	}
+	return cmp.Compare(id, otherID)
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"goweather/pkg/types"
+)
+
+// stores returns a MemoryStore and a SQLite database in a temp dir, so the
+// history tests also check that the two page the same way.
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "weather.sqlite"))
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return map[string]Store{DriverMemory: NewMemoryStore(), DriverSQLite: db}
+}
+
+// seed saves one row per request count, in order. Rows saved together share
+// a created_at second, so created_at pages are ordered by id within it.
+func seed(t *testing.T, store Store, counts ...int) []*types.WeatherQuery {
+	t.Helper()
+	queries := make([]*types.WeatherQuery, len(counts))
+	for i, count := range counts {
+		queries[i] = &types.WeatherQuery{Location: "istanbul", RequestCount: count}
+	}
+	if err := store.SaveWeatherQueries(context.Background(), queries); err != nil {
+		t.Fatalf("SaveWeatherQueries: %v", err)
+	}
+	return queries
+}
+
+// pageAll follows next_cursor until the last page and returns the row ids
+// in the order they were served.
+func pageAll(t *testing.T, store Store, q HistoryQuery) []int {
+	t.Helper()
+	var ids []int
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatalf("pagination did not end")
+		}
+		page, err := store.QueryHistory(context.Background(), q)
+		if err != nil {
+			t.Fatalf("QueryHistory: %v", err)
+		}
+		if len(page.Items) > q.Limit {
+			t.Fatalf("page has %d rows, limit %d", len(page.Items), q.Limit)
+		}
+		for _, row := range page.Items {
+			ids = append(ids, row.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestQueryHistoryPagination(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			rows := seed(t, store, 3, 1, 4, 1, 5)
+			id := func(i int) int { return rows[i].ID }
+
+			tests := []struct {
+				name       string
+				sort       string
+				descending bool
+				want       []int
+			}{
+				{"newest first", HistorySortCreatedAt, true, []int{id(4), id(3), id(2), id(1), id(0)}},
+				{"oldest first", HistorySortCreatedAt, false, []int{id(0), id(1), id(2), id(3), id(4)}},
+				{"busiest first", HistorySortRequestCount, true, []int{id(4), id(2), id(0), id(3), id(1)}},
+				{"quietest first", HistorySortRequestCount, false, []int{id(1), id(3), id(0), id(2), id(4)}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got := pageAll(t, store, HistoryQuery{Sort: tt.sort, Descending: tt.descending, Limit: 2})
+					if !slices.Equal(got, tt.want) {
+						t.Errorf("ids = %v, want %v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestQueryHistoryStablePages(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			rows := seed(t, store, 1, 1, 1)
+			q := HistoryQuery{Sort: HistorySortCreatedAt, Descending: true, Limit: 2}
+			first, err := store.QueryHistory(context.Background(), q)
+			if err != nil {
+				t.Fatalf("QueryHistory: %v", err)
+			}
+
+			// rows written between pages must not shift the next one
+			seed(t, store, 1)
+			q.Cursor = first.NextCursor
+			second, err := store.QueryHistory(context.Background(), q)
+			if err != nil {
+				t.Fatalf("QueryHistory: %v", err)
+			}
+			if len(second.Items) != 1 || second.Items[0].ID != rows[0].ID || second.NextCursor != "" {
+				t.Errorf("second page = %+v, want only row %d", second, rows[0].ID)
+			}
+		})
+	}
+}
+
+func TestQueryHistoryCursorMismatch(t *testing.T) {
+	store := NewMemoryStore()
+	seed(t, store, 1, 2, 3)
+	q := HistoryQuery{Sort: HistorySortCreatedAt, Descending: true, Limit: 1}
+	page, err := store.QueryHistory(context.Background(), q)
+	if err != nil {
+		t.Fatalf("QueryHistory: %v", err)
+	}
+
+	for _, other := range []HistoryQuery{
+		{Sort: HistorySortRequestCount, Descending: true, Limit: 1, Cursor: page.NextCursor},
+		{Sort: HistorySortCreatedAt, Descending: false, Limit: 1, Cursor: page.NextCursor},
+		{Sort: HistorySortCreatedAt, Descending: true, Limit: 1, Cursor: "not-a-cursor"},
+	} {
+		if _, err := store.QueryHistory(context.Background(), other); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("QueryHistory(%+v) error = %v, want ErrInvalidCursor", other, err)
+		}
+	}
+}
+
+func TestHistoryTenantFilter(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			queries := []*types.WeatherQuery{
+				{Location: "istanbul", RequestCount: 3, Tenants: []string{"mobile", "web"}},
+				{Location: "ankara", RequestCount: 1, Tenants: []string{"web"}},
+				{Location: "izmir", RequestCount: 2},
+			}
+			if err := store.SaveWeatherQueries(context.Background(), queries); err != nil {
+				t.Fatalf("SaveWeatherQueries: %v", err)
+			}
+
+			tests := []struct {
+				tenant        string
+				want          []int
+				totalRequests int
+			}{
+				{"", []int{queries[2].ID, queries[1].ID, queries[0].ID}, 6},
+				{"mobile", []int{queries[0].ID}, 3},
+				{"web", []int{queries[1].ID, queries[0].ID}, 4},
+				{"other", nil, 0},
+			}
+			for _, tt := range tests {
+				filter := HistoryFilter{TenantID: tt.tenant}
+				got := pageAll(t, store, HistoryQuery{HistoryFilter: filter, Sort: HistorySortCreatedAt, Descending: true, Limit: 10})
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("tenant %q: ids = %v, want %v", tt.tenant, got, tt.want)
+				}
+				stats, err := store.HistoryStats(context.Background(), filter)
+				if err != nil {
+					t.Fatalf("HistoryStats: %v", err)
+				}
+				if stats.Fetches != len(tt.want) || stats.TotalRequests != tt.totalRequests {
+					t.Errorf("tenant %q: stats = %d fetches / %d requests, want %d / %d",
+						tt.tenant, stats.Fetches, stats.TotalRequests, len(tt.want), tt.totalRequests)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryStatsTemperatures(t *testing.T) {
+	temperature := func(value float64) *float64 { return &value }
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			queries := []*types.WeatherQuery{
+				{Location: "istanbul", RequestCount: 4, Service1Temp: temperature(10), Service2Temp: temperature(20)},
+				{Location: "istanbul", RequestCount: 2, Service1Temp: temperature(30)},
+				{Location: "istanbul", RequestCount: 3}, // every provider failed
+				{Location: "ankara", RequestCount: 1, Service1Temp: temperature(-5), Service2Temp: temperature(-5)},
+			}
+			if err := store.SaveWeatherQueries(context.Background(), queries); err != nil {
+				t.Fatalf("SaveWeatherQueries: %v", err)
+			}
+
+			stats, err := store.HistoryStats(context.Background(), HistoryFilter{Location: "istanbul"})
+			if err != nil {
+				t.Fatalf("HistoryStats: %v", err)
+			}
+			if stats.Fetches != 3 || stats.TotalRequests != 9 || stats.AvgBatchSize != 3 {
+				t.Errorf("stats = %d fetches / %d requests / %v avg, want 3 / 9 / 3",
+					stats.Fetches, stats.TotalRequests, stats.AvgBatchSize)
+			}
+			if stats.MinTemp == nil || *stats.MinTemp != 15 || stats.MaxTemp == nil || *stats.MaxTemp != 30 ||
+				stats.AvgTemp == nil || *stats.AvgTemp != 22.5 {
+				t.Errorf("temperatures = %v / %v / %v, want 15 / 30 / 22.5", stats.MinTemp, stats.MaxTemp, stats.AvgTemp)
+			}
+
+			empty, err := store.HistoryStats(context.Background(), HistoryFilter{Location: "izmir"})
+			if err != nil {
+				t.Fatalf("HistoryStats: %v", err)
+			}
+			if empty.Fetches != 0 || empty.MinTemp != nil || empty.AvgTemp != nil {
+				t.Errorf("stats without rows = %+v, want zero counts and nil temperatures", empty)
+			}
+		})
+	}
+}
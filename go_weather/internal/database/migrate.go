@@ -0,0 +1,329 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change loaded from migrations/ for
+// SQLite or migrations/postgres/ for PostgreSQL. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrationsDir is where the migrations of the database's driver live;
+// both directories keep the same versions and names.
+func (d *Database) migrationsDir() string {
+	if d.driver == DriverPostgres {
+		return "migrations/postgres"
+	}
+	return "migrations"
+}
+
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migration files read failed: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionPart, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", fileName)
+		}
+		version, err := strconv.Atoi(versionPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", fileName, err)
+		}
+
+		content, err := migrationFiles.ReadFile(dir + "/" + fileName)
+		if err != nil {
+			return nil, fmt.Errorf("migration file read failed: %v", err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (d *Database) ensureMigrationsTable() error {
+	if d.driver == DriverPostgres {
+		// replicas starting together wait for each other instead of racing
+		return d.inTransaction(func(tx *sql.Tx) error {
+			if err := lockMigrations(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMP(0) DEFAULT CURRENT_TIMESTAMP
+			)`)
+			if err != nil {
+				return fmt.Errorf("schema_migrations creation failed: %v", err)
+			}
+			return nil
+		})
+	}
+
+	var tableCount int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&tableCount)
+	if err != nil {
+		return fmt.Errorf("schema_migrations lookup failed: %v", err)
+	}
+	if tableCount > 0 {
+		return nil
+	}
+
+	_, err = d.db.Exec(`
+	CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("schema_migrations creation failed: %v", err)
+	}
+
+	return d.baselineLegacySchema()
+}
+
+// baselineLegacySchema records the migrations a database created before
+// versioned migrations already has, so they aren't applied twice.
+func (d *Database) baselineLegacySchema() error {
+	columns, err := d.tableColumns("weather_queries")
+	if err != nil || len(columns) == 0 {
+		return err
+	}
+
+	baseline := []Migration{{Version: 1, Name: "create_weather_queries"}}
+	if columns["providers"] {
+		baseline = append(baseline, Migration{Version: 2, Name: "nullable_provider_temperatures"})
+	}
+	for _, migration := range baseline {
+		if _, err := d.db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, migration.Version, migration.Name); err != nil {
+			return fmt.Errorf("migration baseline failed: %v", err)
+		}
+	}
+
+	log.Printf("✅ Existing schema baselined at version %d", baseline[len(baseline)-1].Version)
+	return nil
+}
+
+func (d *Database) tableColumns(table string) (map[string]bool, error) {
+	rows, err := d.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("table info read failed: %v", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("table info read failed: %v", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func (d *Database) appliedMigrations() (map[int]time.Time, error) {
+	if err := d.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("applied migrations read failed: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("applied migrations read failed: %v", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Migrate applies every pending up migration, each in its own transaction,
+// and returns how many were applied.
+func (d *Database) Migrate() (int, error) {
+	migrations, err := loadMigrations(d.migrationsDir())
+	if err != nil {
+		return 0, err
+	}
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		skipped := false
+		err := d.inTransaction(func(tx *sql.Tx) error {
+			if d.driver == DriverPostgres {
+				// another replica may have applied it while this one waited
+				if err := lockMigrations(tx); err != nil {
+					return err
+				}
+				var done int
+				if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, migration.Version).Scan(&done); err != nil {
+					return err
+				}
+				if skipped = done > 0; skipped {
+					return nil
+				}
+			}
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(d.rebind(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`), migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+		if skipped {
+			continue
+		}
+		log.Printf("✅ Migration applied: %d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// MigrateDown reverts the latest `steps` applied migrations, newest first.
+func (d *Database) MigrateDown(steps int) (int, error) {
+	migrations, err := loadMigrations(d.migrationsDir())
+	if err != nil {
+		return 0, err
+	}
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return count, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+		err := d.inTransaction(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(d.rebind(`DELETE FROM schema_migrations WHERE version = ?`), migration.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d_%s rollback failed: %v", migration.Version, migration.Name, err)
+		}
+		log.Printf("✅ Migration reverted: %d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// MigrationStatus lists every known migration and whether it is applied.
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(d.migrationsDir())
+	if err != nil {
+		return nil, err
+	}
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (d *Database) inTransaction(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS weather_queries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	service_1_temperature REAL NOT NULL,
+	service_2_temperature REAL NOT NULL,
+	request_count INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- Rows with a missing provider temperature can't satisfy NOT NULL and are dropped.
+CREATE TABLE weather_queries_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	service_1_temperature REAL NOT NULL,
+	service_2_temperature REAL NOT NULL,
+	request_count INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO weather_queries_old (id, location, service_1_temperature, service_2_temperature, request_count, created_at)
+SELECT id, location, service_1_temperature, service_2_temperature, request_count, created_at FROM weather_queries
+WHERE service_1_temperature IS NOT NULL AND service_2_temperature IS NOT NULL;
+
+DROP TABLE weather_queries;
+ALTER TABLE weather_queries_old RENAME TO weather_queries;
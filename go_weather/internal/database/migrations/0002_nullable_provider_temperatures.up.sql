@@ -0,0 +1,17 @@
+-- A failed provider is stored as NULL and the contributing providers are
+-- listed. SQLite can't drop NOT NULL in place, so the table is rebuilt.
+CREATE TABLE weather_queries_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	location TEXT NOT NULL,
+	service_1_temperature REAL,
+	service_2_temperature REAL,
+	request_count INTEGER NOT NULL,
+	providers TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO weather_queries_new (id, location, service_1_temperature, service_2_temperature, request_count, created_at)
+SELECT id, location, service_1_temperature, service_2_temperature, request_count, created_at FROM weather_queries;
+
+DROP TABLE weather_queries;
+ALTER TABLE weather_queries_new RENAME TO weather_queries;
@@ -0,0 +1,3 @@
+DROP TABLE tenant_usage;
+DROP TABLE api_keys;
+DROP TABLE tenants;
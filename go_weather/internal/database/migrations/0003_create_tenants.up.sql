@@ -0,0 +1,26 @@
+-- Tenants are the internal teams billed by usage. API keys are stored as
+-- SHA-256 hashes; only the prefix is kept in clear to tell keys apart.
+CREATE TABLE tenants (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	daily_quota INTEGER NOT NULL DEFAULT 0,
+	monthly_quota INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT NOT NULL REFERENCES tenants(id),
+	key_hash TEXT NOT NULL UNIQUE,
+	prefix TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	revoked_at DATETIME
+);
+
+-- One row per tenant and period: "2026-10-16" for days, "2026-10" for months.
+CREATE TABLE tenant_usage (
+	tenant_id TEXT NOT NULL REFERENCES tenants(id),
+	period TEXT NOT NULL,
+	request_count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (tenant_id, period)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE weather_queries DROP COLUMN provider_errors;
+ALTER TABLE weather_queries DROP COLUMN provider_latencies;
+ALTER TABLE weather_queries DROP COLUMN fetch_ms;
+ALTER TABLE weather_queries DROP COLUMN wait_ms;
+ALTER TABLE weather_queries DROP COLUMN trigger_reason;
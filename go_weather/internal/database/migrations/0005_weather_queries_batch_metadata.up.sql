@@ -0,0 +1,10 @@
+-- Per-fetch audit data. Rows written before this migration keep NULLs.
+-- trigger_reason: timer, max_reached, flush, manual or direct (no aggregation)
+-- wait_ms: how long the oldest caller waited before the fetch started
+-- fetch_ms: time spent upstream for the whole fetch
+-- provider_latencies / provider_errors: JSON objects keyed by provider name
+ALTER TABLE weather_queries ADD COLUMN trigger_reason TEXT;
+ALTER TABLE weather_queries ADD COLUMN wait_ms INTEGER;
+ALTER TABLE weather_queries ADD COLUMN fetch_ms INTEGER;
+ALTER TABLE weather_queries ADD COLUMN provider_latencies TEXT;
+ALTER TABLE weather_queries ADD COLUMN provider_errors TEXT;
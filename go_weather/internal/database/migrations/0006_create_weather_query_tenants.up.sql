@@ -0,0 +1,8 @@
+-- Which tenants each fetch served, so /history can show a tenant only the
+-- fetches it took part in. One aggregated fetch can serve several tenants;
+-- anonymous callers and rows written before this migration have no entry.
+CREATE TABLE weather_query_tenants (
+	tenant_id TEXT NOT NULL,
+	query_id INTEGER NOT NULL REFERENCES weather_queries(id),
+	PRIMARY KEY (tenant_id, query_id)
+);
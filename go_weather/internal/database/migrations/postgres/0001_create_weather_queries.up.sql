@@ -0,0 +1,10 @@
+-- PostgreSQL counterpart of ../0001; timestamps are UTC to the second
+-- like SQLite's CURRENT_TIMESTAMP (the connection runs in UTC).
+CREATE TABLE IF NOT EXISTS weather_queries (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	location TEXT NOT NULL,
+	service_1_temperature DOUBLE PRECISION NOT NULL,
+	service_2_temperature DOUBLE PRECISION NOT NULL,
+	request_count INTEGER NOT NULL,
+	created_at TIMESTAMP(0) DEFAULT CURRENT_TIMESTAMP
+);
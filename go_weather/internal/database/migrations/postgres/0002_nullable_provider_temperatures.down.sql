@@ -0,0 +1,5 @@
+-- Rows with a missing provider temperature can't satisfy NOT NULL and are dropped.
+DELETE FROM weather_queries WHERE service_1_temperature IS NULL OR service_2_temperature IS NULL;
+ALTER TABLE weather_queries DROP COLUMN providers;
+ALTER TABLE weather_queries ALTER COLUMN service_1_temperature SET NOT NULL;
+ALTER TABLE weather_queries ALTER COLUMN service_2_temperature SET NOT NULL;
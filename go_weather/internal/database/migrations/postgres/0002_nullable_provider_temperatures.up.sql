@@ -0,0 +1,4 @@
+-- A failed provider is stored as NULL and the contributing providers are listed.
+ALTER TABLE weather_queries ALTER COLUMN service_1_temperature DROP NOT NULL;
+ALTER TABLE weather_queries ALTER COLUMN service_2_temperature DROP NOT NULL;
+ALTER TABLE weather_queries ADD COLUMN providers TEXT;
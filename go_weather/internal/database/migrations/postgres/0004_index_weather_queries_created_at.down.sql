@@ -0,0 +1,2 @@
+DROP INDEX idx_weather_queries_location_created_at;
+DROP INDEX idx_weather_queries_created_at;
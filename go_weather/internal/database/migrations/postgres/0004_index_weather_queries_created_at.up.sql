@@ -0,0 +1,3 @@
+-- /history filters by location and time range and pages in created_at order
+CREATE INDEX idx_weather_queries_created_at ON weather_queries (created_at, id);
+CREATE INDEX idx_weather_queries_location_created_at ON weather_queries (location, created_at, id);
@@ -0,0 +1,7 @@
+-- Per-fetch audit data, see ../0005. The provider maps are JSONB so they can
+-- be queried in place. Rows written before this migration keep NULLs.
+ALTER TABLE weather_queries ADD COLUMN trigger_reason TEXT;
+ALTER TABLE weather_queries ADD COLUMN wait_ms BIGINT;
+ALTER TABLE weather_queries ADD COLUMN fetch_ms BIGINT;
+ALTER TABLE weather_queries ADD COLUMN provider_latencies JSONB;
+ALTER TABLE weather_queries ADD COLUMN provider_errors JSONB;
@@ -0,0 +1,6 @@
+-- Which tenants each fetch served, see ../0006.
+CREATE TABLE weather_query_tenants (
+	tenant_id TEXT NOT NULL,
+	query_id BIGINT NOT NULL REFERENCES weather_queries(id),
+	PRIMARY KEY (tenant_id, query_id)
+);
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// migrationLockID is the advisory lock replicas take while migrating
+const migrationLockID = 4830211
+
+// NewPostgres connects to PostgreSQL and applies pending migrations.
+// Several server replicas can share one database.
+func NewPostgres(url string) (*Database, error) {
+	database, err := OpenPostgres(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("schema migration failed: %v", err)
+	}
+
+	return database, nil
+}
+
+// OpenPostgres connects without touching the schema. Sessions run in UTC so
+// CURRENT_TIMESTAMP defaults match the UTC timestamps SQLite stores.
+func OpenPostgres(url string) (*Database, error) {
+	config, err := pgx.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("database url invalid: %v", err)
+	}
+	config.RuntimeParams["timezone"] = "UTC"
+
+	db := stdlib.OpenDB(*config)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database connection test failed: %v", err)
+	}
+
+	log.Printf("✅ PostgreSQL database connection successful: %s@%s:%d/%s", config.User, config.Host, config.Port, config.Database)
+	return &Database{db: db, driver: DriverPostgres}, nil
+}
+
+// rebind rewrites the ? placeholders used throughout this package as $1,
+// $2, ... for PostgreSQL. None of the queries has a literal question mark.
+func (d *Database) rebind(query string) string {
+	if d.driver != DriverPostgres {
+		return query
+	}
+	var rebound strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			rebound.WriteRune(c)
+			continue
+		}
+		n++
+		rebound.WriteString("$" + strconv.Itoa(n))
+	}
+	return rebound.String()
+}
+
+// lockMigrations serializes migrations across replicas until tx ends.
+func lockMigrations(tx *sql.Tx) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("migration lock failed: %v", err)
+	}
+	return nil
+}
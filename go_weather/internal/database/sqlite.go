@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -10,11 +12,37 @@ import (
 	"goweather/pkg/types"
 )
 
+// Database drivers, selected by DATABASE_DRIVER
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Database is the SQL Store. The same queries run on SQLite and on
+// PostgreSQL; see rebind for the placeholder difference.
 type Database struct {
-	db *sql.DB
+	db     *sql.DB
+	driver string
 }
 
+// NewDatabase opens the SQLite file and applies pending migrations.
 func NewDatabase(dbPath string) (*Database, error) {
+	database, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("schema migration failed: %v", err)
+	}
+
+	return database, nil
+}
+
+// Open connects to the SQLite file without touching the schema; the
+// migrate subcommand uses it to inspect and change schema versions.
+func Open(dbPath string) (*Database, error) {
 	
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		file, err := os.Create(dbPath)
@@ -34,65 +62,70 @@ func NewDatabase(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("database connection test failed: %v", err)
 	}
 
-	database := &Database{db: db}
-	
-	if err := database.createTable(); err != nil {
-		return nil, fmt.Errorf("table creation failed: %v", err)
-	}
-
 	log.Printf("✅ SQLite database connection successful: %s", dbPath)
-	return database, nil
+	return &Database{db: db, driver: DriverSQLite}, nil
 }
 
-func (d *Database) createTable() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS weather_queries (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		location TEXT NOT NULL,
-		service_1_temperature REAL NOT NULL,
-		service_2_temperature REAL NOT NULL,
-		request_count INTEGER NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	_, err := d.db.Exec(query)
+// Driver returns DriverSQLite or DriverPostgres.
+func (d *Database) Driver() string {
+	return d.driver
+}
+
+
+func (d *Database) SaveWeatherQuery(query *types.WeatherQuery) error {
+	err := d.db.QueryRow(d.rebind(insertWeatherQuery), insertArgs(query)...).Scan(&query.ID)
 	if err != nil {
-		return fmt.Errorf("table creation failed: %v", err)
+		return fmt.Errorf("data save failed: %v", err)
 	}
 
-	log.Println("✅ weather_queries table created")
+	log.Printf("✅ Weather query saved: ID=%d, Location=%s", query.ID, query.Location)
 	return nil
 }
 
+// SaveWeatherQueries inserts a batch of rows in a single transaction.
+func (d *Database) SaveWeatherQueries(ctx context.Context, queries []*types.WeatherQuery) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction begin failed: %v", err)
+	}
+	defer tx.Rollback()
 
-func (d *Database) SaveWeatherQuery(query *types.WeatherQuery) error {
-	stmt := `
-	INSERT INTO weather_queries (location, service_1_temperature, service_2_temperature, request_count)
-	VALUES (?, ?, ?, ?)`
-
-	result, err := d.db.Exec(stmt, query.Location, query.Service1Temp, query.Service2Temp, query.RequestCount)
+	stmt, err := tx.PrepareContext(ctx, d.rebind(insertWeatherQuery))
 	if err != nil {
-		return fmt.Errorf("data save failed: %v", err)
+		return fmt.Errorf("statement prepare failed: %v", err)
 	}
+	defer stmt.Close()
 
-	// get ID
-	id, err := result.LastInsertId()
+	tenantStmt, err := tx.PrepareContext(ctx, d.rebind(insertWeatherQueryTenant))
 	if err != nil {
-		return fmt.Errorf("ID get failed: %v", err)
+		return fmt.Errorf("statement prepare failed: %v", err)
 	}
+	defer tenantStmt.Close()
 
-	query.ID = int(id)
-	log.Printf("✅ Weather query saved: ID=%d, Location=%s", query.ID, query.Location)
+	for _, query := range queries {
+		if err := stmt.QueryRowContext(ctx, insertArgs(query)...).Scan(&query.ID); err != nil {
+			return fmt.Errorf("data save failed: %v", err)
+		}
+		for _, tenantID := range query.Tenants {
+			if _, err := tenantStmt.ExecContext(ctx, tenantID, query.ID); err != nil {
+				return fmt.Errorf("tenant save failed: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit failed: %v", err)
+	}
 	return nil
 }
 
-func (d *Database) GetWeatherQueries() ([]types.WeatherQuery, error) {
+func (d *Database) GetWeatherQueries(ctx context.Context) ([]types.WeatherQuery, error) {
 	query := `
-	SELECT id, location, service_1_temperature, service_2_temperature, request_count
+	SELECT ` + weatherQueryColumns + `
 	FROM weather_queries
 	ORDER BY created_at DESC`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("data get failed: %v", err)
 	}
@@ -100,8 +133,7 @@ func (d *Database) GetWeatherQueries() ([]types.WeatherQuery, error) {
 
 	var queries []types.WeatherQuery
 	for rows.Next() {
-		var q types.WeatherQuery
-		err := rows.Scan(&q.ID, &q.Location, &q.Service1Temp, &q.Service2Temp, &q.RequestCount)
+		q, err := scanWeatherQuery(rows)
 		if err != nil {
 			return nil, fmt.Errorf("data read failed: %v", err)
 		}
@@ -111,6 +143,87 @@ func (d *Database) GetWeatherQueries() ([]types.WeatherQuery, error) {
 	return queries, nil
 }
 
+const insertWeatherQuery = `
+	INSERT INTO weather_queries (location, service_1_temperature, service_2_temperature, request_count, providers,
+		trigger_reason, wait_ms, fetch_ms, provider_latencies, provider_errors)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+const insertWeatherQueryTenant = `
+	INSERT INTO weather_query_tenants (tenant_id, query_id)
+	VALUES (?, ?)
+	ON CONFLICT DO NOTHING`
+
+// weatherQueryColumns is the select list read by scanWeatherQuery
+const weatherQueryColumns = `id, location, service_1_temperature, service_2_temperature, request_count,
+	COALESCE(providers, ''), created_at, COALESCE(trigger_reason, ''), wait_ms, fetch_ms,
+	provider_latencies, provider_errors`
+
+func insertArgs(query *types.WeatherQuery) []any {
+	var trigger any
+	if query.TriggerReason != "" {
+		trigger = query.TriggerReason
+	}
+	return []any{query.Location, query.Service1Temp, query.Service2Temp, query.RequestCount, query.Providers,
+		trigger, query.WaitMs, query.FetchMs, jsonColumn(query.ProviderLatencies), jsonColumn(query.ProviderErrors)}
+}
+
+// jsonColumn encodes a provider map for a TEXT column; empty maps are NULL
+func jsonColumn[V any](value map[string]V) any {
+	if len(value) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// scanWeatherQuery reads one row selected with weatherQueryColumns. Rows
+// logged before the batch metadata columns existed come back with those
+// fields empty.
+func scanWeatherQuery(rows *sql.Rows) (types.WeatherQuery, error) {
+	var q types.WeatherQuery
+	var latencies, providerErrors sql.NullString
+	err := rows.Scan(&q.ID, &q.Location, &q.Service1Temp, &q.Service2Temp, &q.RequestCount, &q.Providers,
+		&q.CreatedAt, &q.TriggerReason, &q.WaitMs, &q.FetchMs, &latencies, &providerErrors)
+	if err != nil {
+		return q, err
+	}
+	if latencies.Valid {
+		if err := json.Unmarshal([]byte(latencies.String), &q.ProviderLatencies); err != nil {
+			return q, fmt.Errorf("provider_latencies of row %d: %v", q.ID, err)
+		}
+	}
+	if providerErrors.Valid {
+		if err := json.Unmarshal([]byte(providerErrors.String), &q.ProviderErrors); err != nil {
+			return q, fmt.Errorf("provider_errors of row %d: %v", q.ID, err)
+		}
+	}
+	return q, nil
+}
+
+// Ping verifies the connection and that the database still accepts writes
+// (SQLite file permissions, a PostgreSQL read-only standby) by running an
+// update inside a transaction that is rolled back.
+func (d *Database) Ping(ctx context.Context) error {
+	if err := d.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %v", err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("database transaction failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE schema_migrations SET version = version WHERE 1 = 0`); err != nil {
+		return fmt.Errorf("database not writable: %v", err)
+	}
+	return nil
+}
+
 func (d *Database) Close() error {
 	if d.db != nil {
 		return d.db.Close()
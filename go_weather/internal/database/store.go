@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+
+	"goweather/pkg/types"
+)
+
+// Store is where upstream fetches are logged and read back. *Database
+// implements it on SQLite or PostgreSQL; MemoryStore keeps the rows in
+// memory for tests.
+type Store interface {
+	// SaveWeatherQueries inserts the rows in one transaction and sets their IDs.
+	SaveWeatherQueries(ctx context.Context, queries []*types.WeatherQuery) error
+	// GetWeatherQueries lists every row, newest first.
+	GetWeatherQueries(ctx context.Context) ([]types.WeatherQuery, error)
+	QueryHistory(ctx context.Context, q HistoryQuery) (*types.HistoryPage, error)
+	HistoryStats(ctx context.Context, filter HistoryFilter) (*types.HistoryStats, error)
+	// Ping reports whether the store can take writes.
+	Ping(ctx context.Context) error
+	// Driver names the backend for logs and traces.
+	Driver() string
+	Close() error
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
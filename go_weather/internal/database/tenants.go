@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+// apiKeyPrefix marks keys issued by this service; the prefix plus a few
+// characters is stored in clear so keys can be told apart when listing.
+const (
+	apiKeyPrefix      = "gw_"
+	apiKeyShownLength = 10
+)
+
+var (
+	ErrTenantNotFound       = errors.New("tenant not found")
+	ErrAPIKeyNotFound       = errors.New("api key not found or revoked")
+	ErrDailyQuotaExceeded   = errors.New("daily quota exceeded")
+	ErrMonthlyQuotaExceeded = errors.New("monthly quota exceeded")
+)
+
+// APIKey is a stored key; the key itself is only known when it is created.
+type APIKey struct {
+	ID        int        `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HashAPIKey is the form keys are stored and looked up in.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// UsagePeriods returns the day and month usage rows that now falls into (UTC).
+func UsagePeriods(now time.Time) (string, string) {
+	now = now.UTC()
+	return now.Format("2006-01-02"), now.Format("2006-01")
+}
+
+func (d *Database) CreateTenant(ctx context.Context, tenant *types.Tenant) error {
+	if tenant.ID == "" {
+		return fmt.Errorf("tenant id is required")
+	}
+	if tenant.DailyQuota < 0 || tenant.MonthlyQuota < 0 {
+		return fmt.Errorf("quotas must not be negative")
+	}
+
+	_, err := d.db.ExecContext(ctx, d.rebind(`
+	INSERT INTO tenants (id, name, daily_quota, monthly_quota)
+	VALUES (?, ?, ?, ?)`), tenant.ID, tenant.Name, tenant.DailyQuota, tenant.MonthlyQuota)
+	if err != nil {
+		return fmt.Errorf("tenant create failed: %v", err)
+	}
+	return nil
+}
+
+// SetTenantQuota changes a tenant's quotas; 0 means unlimited.
+func (d *Database) SetTenantQuota(ctx context.Context, tenantID string, daily, monthly int) error {
+	if daily < 0 || monthly < 0 {
+		return fmt.Errorf("quotas must not be negative")
+	}
+
+	result, err := d.db.ExecContext(ctx, d.rebind(`UPDATE tenants SET daily_quota = ?, monthly_quota = ? WHERE id = ?`), daily, monthly, tenantID)
+	if err != nil {
+		return fmt.Errorf("tenant quota update failed: %v", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+func (d *Database) GetTenants(ctx context.Context) ([]types.Tenant, error) {
+	rows, err := d.db.QueryContext(ctx, d.rebind(`
+	SELECT id, name, daily_quota, monthly_quota, created_at
+	FROM tenants
+	ORDER BY id`))
+	if err != nil {
+		return nil, fmt.Errorf("tenants get failed: %v", err)
+	}
+	defer rows.Close()
+
+	var tenants []types.Tenant
+	for rows.Next() {
+		var t types.Tenant
+		if err := rows.Scan(&t.ID, &t.Name, &t.DailyQuota, &t.MonthlyQuota, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("tenant read failed: %v", err)
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// CreateAPIKey issues a new key for the tenant. Only its hash is stored, so
+// the returned key can't be shown again.
+func (d *Database) CreateAPIKey(ctx context.Context, tenantID string) (string, *APIKey, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("api key generation failed: %v", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	prefix := key[:apiKeyShownLength]
+
+	var exists int
+	err := d.db.QueryRowContext(ctx, d.rebind(`SELECT COUNT(*) FROM tenants WHERE id = ?`), tenantID).Scan(&exists)
+	if err != nil {
+		return "", nil, fmt.Errorf("tenant lookup failed: %v", err)
+	}
+	if exists == 0 {
+		return "", nil, ErrTenantNotFound
+	}
+
+	var id int
+	err = d.db.QueryRowContext(ctx, d.rebind(`
+	INSERT INTO api_keys (tenant_id, key_hash, prefix)
+	VALUES (?, ?, ?)
+	RETURNING id`), tenantID, HashAPIKey(key), prefix).Scan(&id)
+	if err != nil {
+		return "", nil, fmt.Errorf("api key save failed: %v", err)
+	}
+
+	return key, &APIKey{ID: id, TenantID: tenantID, Prefix: prefix, CreatedAt: time.Now().UTC()}, nil
+}
+
+func (d *Database) GetAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	rows, err := d.db.QueryContext(ctx, d.rebind(`
+	SELECT id, tenant_id, prefix, created_at, revoked_at
+	FROM api_keys
+	WHERE tenant_id = ?
+	ORDER BY id`), tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("api keys get failed: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var k APIKey
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&k.ID, &k.TenantID, &k.Prefix, &k.CreatedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("api key read failed: %v", err)
+		}
+		if revokedAt.Valid {
+			k.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (d *Database) RevokeAPIKey(ctx context.Context, id int) error {
+	result, err := d.db.ExecContext(ctx, d.rebind(`
+	UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND revoked_at IS NULL`), id)
+	if err != nil {
+		return fmt.Errorf("api key revoke failed: %v", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TenantByAPIKey resolves a key sent by a caller to its tenant.
+func (d *Database) TenantByAPIKey(ctx context.Context, key string) (*types.Tenant, error) {
+	var t types.Tenant
+	err := d.db.QueryRowContext(ctx, d.rebind(`
+	SELECT t.id, t.name, t.daily_quota, t.monthly_quota, t.created_at
+	FROM api_keys k
+	JOIN tenants t ON t.id = k.tenant_id
+	WHERE k.key_hash = ? AND k.revoked_at IS NULL`), HashAPIKey(key)).
+		Scan(&t.ID, &t.Name, &t.DailyQuota, &t.MonthlyQuota, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("api key lookup failed: %v", err)
+	}
+	return &t, nil
+}
+
+// ConsumeQuota counts requests against the tenant's day and month. The
+// counters are bumped first so the transaction holds the write lock before
+// it reads them; a request over either quota is rolled back and not counted.
+func (d *Database) ConsumeQuota(ctx context.Context, tenant *types.Tenant, now time.Time, requests int) (types.TenantUsage, error) {
+	day, month := UsagePeriods(now)
+	usage := types.TenantUsage{TenantID: tenant.ID, Day: day, Month: month}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return usage, fmt.Errorf("transaction begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	counts := make([]int, 2)
+	for i, period := range []string{day, month} {
+		err := tx.QueryRowContext(ctx, d.rebind(`
+		INSERT INTO tenant_usage (tenant_id, period, request_count) VALUES (?, ?, ?)
+		ON CONFLICT (tenant_id, period) DO UPDATE SET request_count = tenant_usage.request_count + excluded.request_count
+		RETURNING request_count`), tenant.ID, period, requests).Scan(&counts[i])
+		if err != nil {
+			return usage, fmt.Errorf("usage update failed: %v", err)
+		}
+	}
+	usage.DayCount, usage.MonthCount = counts[0], counts[1]
+
+	if tenant.DailyQuota > 0 && usage.DayCount > tenant.DailyQuota {
+		usage.DayCount -= requests
+		usage.MonthCount -= requests
+		return usage, ErrDailyQuotaExceeded
+	}
+	if tenant.MonthlyQuota > 0 && usage.MonthCount > tenant.MonthlyQuota {
+		usage.DayCount -= requests
+		usage.MonthCount -= requests
+		return usage, ErrMonthlyQuotaExceeded
+	}
+
+	if err := tx.Commit(); err != nil {
+		return usage, fmt.Errorf("transaction commit failed: %v", err)
+	}
+	return usage, nil
+}
+
+// Usage returns the tenant's request counts for the day and month of now.
+func (d *Database) Usage(ctx context.Context, tenantID string, now time.Time) (types.TenantUsage, error) {
+	day, month := UsagePeriods(now)
+	usage := types.TenantUsage{TenantID: tenantID, Day: day, Month: month}
+
+	rows, err := d.db.QueryContext(ctx, d.rebind(`
+	SELECT period, request_count FROM tenant_usage
+	WHERE tenant_id = ? AND period IN (?, ?)`), tenantID, day, month)
+	if err != nil {
+		return usage, fmt.Errorf("usage get failed: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var period string
+		var count int
+		if err := rows.Scan(&period, &count); err != nil {
+			return usage, fmt.Errorf("usage read failed: %v", err)
+		}
+		if period == day {
+			usage.DayCount = count
+		} else {
+			usage.MonthCount = count
+		}
+	}
+	return usage, rows.Err()
+}
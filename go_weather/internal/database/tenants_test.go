@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+var t0 = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+func tenantDB(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "weather.sqlite"))
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func createTenant(t *testing.T, db *Database, tenant *types.Tenant) {
+	t.Helper()
+	if err := db.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+}
+
+func TestConsumeQuota(t *testing.T) {
+	type consume struct {
+		at       time.Time
+		requests int
+		err      error
+		day      int // usage stored afterwards
+		month    int
+	}
+	tests := []struct {
+		name    string
+		daily   int
+		monthly int
+		steps   []consume
+	}{
+		{"no quotas", 0, 0, []consume{
+			{t0, 100, nil, 100, 100},
+			{t0, 100, nil, 200, 200},
+		}},
+		{"daily quota", 3, 0, []consume{
+			{t0, 2, nil, 2, 2},
+			{t0, 2, ErrDailyQuotaExceeded, 2, 2}, // over by one: nothing is counted
+			{t0, 1, nil, 3, 3},
+			{t0, 1, ErrDailyQuotaExceeded, 3, 3},
+			{t0.AddDate(0, 0, 1), 1, nil, 1, 4}, // next UTC day starts over
+		}},
+		{"monthly quota", 0, 5, []consume{
+			{t0, 3, nil, 3, 3},
+			{t0.AddDate(0, 0, 1), 2, nil, 2, 5},
+			{t0.AddDate(0, 0, 2), 1, ErrMonthlyQuotaExceeded, 0, 5},
+			{t0.AddDate(0, 1, 0), 1, nil, 1, 1}, // next UTC month starts over
+		}},
+		{"daily checked before monthly", 2, 2, []consume{
+			{t0, 3, ErrDailyQuotaExceeded, 0, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := tenantDB(t)
+			tenant := &types.Tenant{ID: "acme", DailyQuota: tt.daily, MonthlyQuota: tt.monthly}
+			createTenant(t, db, tenant)
+
+			for i, step := range tt.steps {
+				usage, err := db.ConsumeQuota(context.Background(), tenant, step.at, step.requests)
+				if !errors.Is(err, step.err) {
+					t.Fatalf("step %d: err = %v, want %v", i+1, err, step.err)
+				}
+				if usage.DayCount != step.day || usage.MonthCount != step.month {
+					t.Errorf("step %d: returned usage = %d/%d, want %d/%d", i+1, usage.DayCount, usage.MonthCount, step.day, step.month)
+				}
+				stored, err := db.Usage(context.Background(), tenant.ID, step.at)
+				if err != nil {
+					t.Fatalf("Usage: %v", err)
+				}
+				if stored.DayCount != step.day || stored.MonthCount != step.month {
+					t.Errorf("step %d: stored usage = %d/%d, want %d/%d", i+1, stored.DayCount, stored.MonthCount, step.day, step.month)
+				}
+			}
+		})
+	}
+}
+
+func TestConsumeQuotaSeparatesTenants(t *testing.T) {
+	db := tenantDB(t)
+	acme := &types.Tenant{ID: "acme", DailyQuota: 1}
+	globex := &types.Tenant{ID: "globex", DailyQuota: 1}
+	createTenant(t, db, acme)
+	createTenant(t, db, globex)
+
+	if _, err := db.ConsumeQuota(context.Background(), acme, t0, 1); err != nil {
+		t.Fatalf("acme: %v", err)
+	}
+	if _, err := db.ConsumeQuota(context.Background(), globex, t0, 1); err != nil {
+		t.Errorf("globex after acme used its quota: %v", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	db := tenantDB(t)
+	createTenant(t, db, &types.Tenant{ID: "acme", Name: "Acme", DailyQuota: 100})
+
+	if _, _, err := db.CreateAPIKey(ctx, "globex"); !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("CreateAPIKey for an unknown tenant: err = %v, want ErrTenantNotFound", err)
+	}
+
+	key, stored, err := db.CreateAPIKey(ctx, "acme")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	tenant, err := db.TenantByAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("TenantByAPIKey: %v", err)
+	}
+	if tenant.ID != "acme" || tenant.DailyQuota != 100 {
+		t.Errorf("TenantByAPIKey = %+v, want acme with its quota", tenant)
+	}
+
+	if _, err := db.TenantByAPIKey(ctx, key+"0"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("unknown key: err = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	if err := db.RevokeAPIKey(ctx, stored.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if _, err := db.TenantByAPIKey(ctx, key); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoked key: err = %v, want ErrAPIKeyNotFound", err)
+	}
+	if err := db.RevokeAPIKey(ctx, stored.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoking twice: err = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	keys, err := db.GetAPIKeys(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetAPIKeys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Errorf("GetAPIKeys = %+v, want the key marked revoked", keys)
+	}
+}
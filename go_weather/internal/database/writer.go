@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goweather/internal/logger"
+	"goweather/internal/metrics"
+	"goweather/internal/tracing"
+	"goweather/pkg/types"
+)
+
+// Drop policies for a full write queue
+const (
+	DropPolicyDrop  = "drop"  // reject the new row immediately
+	DropPolicyBlock = "block" // wait up to BlockTimeout for room, then reject
+)
+
+type WriterConfig struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	DropPolicy    string
+	BlockTimeout  time.Duration
+}
+
+// WriterStats is a snapshot of the writer counters.
+type WriterStats struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Written       uint64 `json:"written"`
+	Dropped       uint64 `json:"dropped"`
+	Failed        uint64 `json:"failed"`
+	Batches       uint64 `json:"batches"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+}
+
+// Writer persists weather queries off the request path: rows go into a
+// bounded queue and a single goroutine inserts them in transactions of up
+// to BatchSize rows, or whatever is queued every FlushInterval.
+type Writer struct {
+	db     Store
+	config WriterConfig
+	logger *logger.Logger
+
+	queue   chan queuedQuery
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	closeOnce sync.Once
+	// closeMutex is held for reading across Enqueue's closed check and send,
+	// so once Close has set closed no row can slip in behind its drain.
+	closeMutex sync.RWMutex
+	closed     bool
+
+	enqueued atomic.Uint64
+	written  atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// queuedQuery is a row plus the context of the batch that produced it,
+// kept for its request IDs and span.
+type queuedQuery struct {
+	ctx   context.Context
+	query *types.WeatherQuery
+}
+
+func NewWriter(db Store, cfg WriterConfig) *Writer {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 500 * time.Millisecond
+	}
+	if cfg.DropPolicy != DropPolicyBlock {
+		cfg.DropPolicy = DropPolicyDrop
+	}
+
+	w := &Writer{
+		db:      db,
+		config:  cfg,
+		logger:  logger.Get(),
+		queue:   make(chan queuedQuery, cfg.QueueSize),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Enqueue hands a row to the writer without touching the database. It
+// returns false when the row was dropped because the queue is full or the
+// writer is closed. ctx only supplies the request IDs and span the write
+// is attributed to; it may already be done.
+func (w *Writer) Enqueue(ctx context.Context, query *types.WeatherQuery) bool {
+	item := queuedQuery{ctx: ctx, query: query}
+	w.closeMutex.RLock()
+	defer w.closeMutex.RUnlock()
+	if w.closed {
+		w.drop(item, "writer closed")
+		return false
+	}
+
+	select {
+	case w.queue <- item:
+		w.enqueued.Add(1)
+		metrics.DBQueueDepth.Set(float64(len(w.queue)))
+		return true
+	default:
+	}
+
+	if w.config.DropPolicy == DropPolicyBlock && w.config.BlockTimeout > 0 {
+		timer := time.NewTimer(w.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case w.queue <- item:
+			w.enqueued.Add(1)
+			metrics.DBQueueDepth.Set(float64(len(w.queue)))
+			return true
+		case <-timer.C:
+		}
+	}
+
+	w.drop(item, "queue full")
+	return false
+}
+
+// Flush returns once every row enqueued before the call has been written,
+// or when ctx expires.
+func (w *Writer) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case w.flushes <- ack:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes the queue and stops the writer goroutine. Rows enqueued
+// afterwards are dropped.
+func (w *Writer) Close(ctx context.Context) error {
+	w.closeMutex.Lock()
+	w.closed = true
+	w.closeMutex.Unlock()
+	err := w.Flush(ctx)
+	w.closeOnce.Do(func() { close(w.stop) })
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) Stats() WriterStats {
+	return WriterStats{
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Failed:        w.failed.Load(),
+		Batches:       w.batches.Load(),
+		QueueDepth:    len(w.queue),
+		QueueCapacity: cap(w.queue),
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]queuedQuery, 0, w.config.BatchSize)
+	for {
+		select {
+		case item := <-w.queue:
+			batch = append(batch, item)
+			if len(batch) >= w.config.BatchSize {
+				batch = w.write(batch)
+			}
+		case <-ticker.C:
+			batch = w.write(batch)
+		case ack := <-w.flushes:
+			batch = w.drain(batch)
+			close(ack)
+		case <-w.stop:
+			w.drain(batch)
+			return
+		}
+	}
+}
+
+// drain writes everything currently queued plus the pending batch.
+func (w *Writer) drain(batch []queuedQuery) []queuedQuery {
+	for {
+		select {
+		case item := <-w.queue:
+			batch = append(batch, item)
+			if len(batch) >= w.config.BatchSize {
+				batch = w.write(batch)
+			}
+		default:
+			return w.write(batch)
+		}
+	}
+}
+
+// write inserts batch in one transaction. Its span starts a new trace
+// linked to the aggregation batch of every row.
+func (w *Writer) write(batch []queuedQuery) []queuedQuery {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	queries := make([]*types.WeatherQuery, len(batch))
+	links := make([]trace.Link, 0, len(batch))
+	for i, item := range batch {
+		queries[i] = item.query
+		if item.ctx != nil {
+			links = append(links, trace.LinkFromContext(item.ctx))
+		}
+	}
+	system := w.db.Driver()
+	if system == DriverPostgres {
+		system = "postgresql" // OpenTelemetry's name for it
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "db.save_weather_queries",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.Int("db.rows", len(batch)),
+		))
+
+	startTime := time.Now()
+	err := w.db.SaveWeatherQueries(ctx, queries)
+	tracing.End(span, err)
+	metrics.DBWriteDuration.Observe(time.Since(startTime).Seconds())
+	metrics.DBQueueDepth.Set(float64(len(w.queue)))
+	if err != nil {
+		w.failed.Add(uint64(len(batch)))
+		metrics.DBRows.WithLabelValues("failed").Add(float64(len(batch)))
+		w.logger.Ctx(ctx).DatabaseError("save_weather_queries", err)
+	} else {
+		w.written.Add(uint64(len(batch)))
+		metrics.DBRows.WithLabelValues("written").Add(float64(len(batch)))
+		w.batches.Add(1)
+		w.logger.Ctx(ctx).DatabaseBatchSaved(len(batch), time.Since(startTime))
+		for _, item := range batch {
+			query := item.query
+			w.logger.Ctx(item.ctx).DatabaseSave(query.Location, query.Service1Temp, query.Service2Temp, query.RequestCount)
+		}
+	}
+
+	clear(batch)
+	return batch[:0]
+}
+
+func (w *Writer) drop(item queuedQuery, reason string) {
+	w.dropped.Add(1)
+	metrics.DBRows.WithLabelValues("dropped").Inc()
+	w.logger.Ctx(item.ctx).DatabaseWriteDropped(item.query.Location, reason, len(w.queue))
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+func TestWriterFlush(t *testing.T) {
+	store := NewMemoryStore()
+	// neither the batch size nor the ticker is reached; only Flush writes
+	w := NewWriter(store, WriterConfig{QueueSize: 10, BatchSize: 100, FlushInterval: time.Hour})
+	defer w.Close(context.Background())
+
+	for _, location := range []string{"istanbul", "ankara", "izmir"} {
+		if !w.Enqueue(context.Background(), &types.WeatherQuery{Location: location, RequestCount: 1}) {
+			t.Fatalf("Enqueue(%s) dropped the row", location)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	rows, err := store.GetWeatherQueries(context.Background())
+	if err != nil {
+		t.Fatalf("GetWeatherQueries: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("store has %d rows after Flush, want 3", len(rows))
+	}
+	stats := w.Stats()
+	if stats.Enqueued != 3 || stats.Written != 3 || stats.Batches != 1 || stats.QueueDepth != 0 {
+		t.Errorf("stats = %+v, want 3 enqueued and written in 1 batch", stats)
+	}
+}
+
+func TestWriterBatchSize(t *testing.T) {
+	store := NewMemoryStore()
+	w := NewWriter(store, WriterConfig{QueueSize: 10, BatchSize: 2, FlushInterval: time.Hour})
+	defer w.Close(context.Background())
+
+	w.Enqueue(context.Background(), &types.WeatherQuery{Location: "istanbul"})
+	w.Enqueue(context.Background(), &types.WeatherQuery{Location: "ankara"})
+
+	// a full batch is written without waiting for Flush or the ticker
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Stats().Written < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("full batch not written, stats = %+v", w.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWriterClose(t *testing.T) {
+	store := NewMemoryStore()
+	w := NewWriter(store, WriterConfig{QueueSize: 10, BatchSize: 100, FlushInterval: time.Hour})
+	w.Enqueue(context.Background(), &types.WeatherQuery{Location: "istanbul"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	rows, _ := store.GetWeatherQueries(context.Background())
+	if len(rows) != 1 {
+		t.Errorf("store has %d rows after Close, want the queued row", len(rows))
+	}
+
+	if w.Enqueue(context.Background(), &types.WeatherQuery{Location: "ankara"}) {
+		t.Errorf("Enqueue after Close accepted the row")
+	}
+	if stats := w.Stats(); stats.Dropped != 1 {
+		t.Errorf("dropped = %d after Close, want 1", stats.Dropped)
+	}
+}
+
+func TestWriterCloseKeepsAcceptedRows(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		store := NewMemoryStore()
+		w := NewWriter(store, WriterConfig{QueueSize: 1000, BatchSize: 10, FlushInterval: time.Hour})
+
+		// enqueue from several goroutines while Close runs; every row
+		// Enqueue accepted must be written, the rest dropped
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					if w.Enqueue(context.Background(), &types.WeatherQuery{Location: "istanbul"}) {
+						accepted.Add(1)
+					}
+				}
+			}()
+		}
+		if err := w.Close(context.Background()); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		wg.Wait()
+
+		rows, _ := store.GetWeatherQueries(context.Background())
+		if int64(len(rows)) != accepted.Load() {
+			t.Fatalf("round %d: %d rows written, %d accepted", round, len(rows), accepted.Load())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"goweather/internal/logger"
+	"goweather/internal/services"
+)
+
+// AdminHandler exposes the aggregation groups to on-call engineers. Every
+// route requires "Authorization: Bearer <ADMIN_TOKEN>".
+type AdminHandler struct {
+	weatherService *services.WeatherService
+	token          string
+	logger         *logger.Logger
+}
+
+type FlushResponse struct {
+	Location string `json:"location"`
+	Flushed  int    `json:"flushed_groups"`
+}
+
+type EvictResponse struct {
+	Location string `json:"location"`
+	Groups   int    `json:"evicted_groups"`
+	Dropped  int    `json:"dropped_requests"`
+}
+
+// AggregationUpdateRequest is the body of PUT /admin/aggregation; omitted
+// fields stay unchanged, location limits the change to one location.
+type AggregationUpdateRequest struct {
+	Location    string `json:"location"`
+	MaxRequests int    `json:"max_requests"`
+	WaitTime    string `json:"wait_time"`
+}
+
+func NewAdminHandler(weatherService *services.WeatherService, token string) *AdminHandler {
+	return &AdminHandler{
+		weatherService: weatherService,
+		token:          token,
+		logger:         logger.Get(),
+	}
+}
+
+// Authenticate rejects requests without the admin bearer token.
+func (h *AdminHandler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !hasAdminToken(r, h.token) {
+			h.logger.Ctx(r.Context()).Warn().
+				Str("component", "admin").
+				Str("action", "unauthorized").
+				Str("path", r.URL.Path).
+				Str("remote_addr", r.RemoteAddr).
+				Msg("Admin request rejected")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, h.logger, http.StatusUnauthorized, "UNAUTHORIZED", "Valid admin bearer token required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// hasAdminToken reports whether r carries "Authorization: Bearer <token>".
+// An empty token matches nothing.
+func hasAdminToken(r *http.Request, token string) bool {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
+
+// Groups serves GET /admin/groups (list) and DELETE /admin/groups?q= (evict).
+func (h *AdminHandler) Groups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, h.logger, http.StatusOK, h.weatherService.ListGroups())
+	case http.MethodDelete:
+		location := r.URL.Query().Get("q")
+		if strings.TrimSpace(location) == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "MISSING_LOCATION", "Location parameter 'q' is required")
+			return
+		}
+		groups, dropped := h.weatherService.EvictLocation(location)
+		if groups == 0 {
+			writeError(w, h.logger, http.StatusNotFound, "GROUP_NOT_FOUND", "No aggregation group for this location")
+			return
+		}
+		writeJSON(w, h.logger, http.StatusOK, EvictResponse{Location: location, Groups: groups, Dropped: dropped})
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Use GET or DELETE")
+	}
+}
+
+// Flush serves POST /admin/groups/flush?q=, firing the location's pending batches now.
+func (h *AdminHandler) Flush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Use POST")
+		return
+	}
+	location := r.URL.Query().Get("q")
+	if strings.TrimSpace(location) == "" {
+		writeError(w, h.logger, http.StatusBadRequest, "MISSING_LOCATION", "Location parameter 'q' is required")
+		return
+	}
+	writeJSON(w, h.logger, http.StatusOK, FlushResponse{Location: location, Flushed: h.weatherService.FlushLocation(location)})
+}
+
+// Aggregation serves GET /admin/aggregation (current settings) and
+// PUT /admin/aggregation (change wait time / max requests at runtime).
+func (h *AdminHandler) Aggregation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, h.logger, http.StatusOK, h.weatherService.AggregationSettings())
+	case http.MethodPut:
+		var request AggregationUpdateRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "INVALID_BODY", fmt.Sprintf("Invalid JSON body: %v", err))
+			return
+		}
+		update := services.AggregationUpdate{Location: request.Location, MaxRequests: request.MaxRequests}
+		if request.WaitTime != "" {
+			waitTime, err := time.ParseDuration(request.WaitTime)
+			if err != nil {
+				writeError(w, h.logger, http.StatusBadRequest, "INVALID_WAIT_TIME", fmt.Sprintf("Invalid wait_time: %v", err))
+				return
+			}
+			update.WaitTime = waitTime
+		}
+		if err := h.weatherService.UpdateAggregation(update); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "INVALID_SETTINGS", err.Error())
+			return
+		}
+		writeJSON(w, h.logger, http.StatusOK, h.weatherService.AggregationSettings())
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Use GET or PUT")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, l *logger.Logger, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		l.Error().
+			Str("component", "handler").
+			Str("action", "json_encode_error").
+			Err(err).
+			Msg("JSON encoding failed")
+	}
+}
+
+func writeError(w http.ResponseWriter, l *logger.Logger, statusCode int, errorCode, message string) {
+	writeJSON(w, l, statusCode, ErrorResponse{
+		Error:   errorCode,
+		Code:    statusCode,
+		Message: message,
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"goweather/internal/middleware"
+)
+
+// maxBatchBody caps the request body of POST /weather/batch
+const maxBatchBody = 1 << 20
+
+type BatchWeatherRequest struct {
+	Locations []string `json:"locations"`
+}
+
+type BatchWeatherResponse struct {
+	Results map[string]WeatherResponse `json:"results"`
+	Errors  map[string]ErrorResponse   `json:"errors"`
+}
+
+// GetWeatherBatch serves POST /weather/batch: every location joins its own
+// aggregation group concurrently and failures are reported per location.
+// fields= and units= apply to every result as they do for /weather.
+func (h *WeatherHandler) GetWeatherBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Use POST with a JSON body")
+		return
+	}
+	startTime := time.Now()
+
+	var request BatchWeatherRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_BODY", fmt.Sprintf("Body must be {\"locations\": [...]}: %v", err))
+		return
+	}
+
+	if len(request.Locations) == 0 {
+		h.sendError(w, http.StatusBadRequest, "MISSING_LOCATION", "At least one location is required")
+		return
+	}
+	if len(request.Locations) > h.weatherService.BatchMaxLocations() {
+		h.sendError(w, http.StatusBadRequest, "TOO_MANY_LOCATIONS",
+			fmt.Sprintf("At most %d locations per batch", h.weatherService.BatchMaxLocations()))
+		return
+	}
+
+	response := BatchWeatherResponse{
+		Results: make(map[string]WeatherResponse),
+		Errors:  make(map[string]ErrorResponse),
+	}
+	var locations []string
+	for _, location := range request.Locations {
+		if strings.TrimSpace(location) == "" {
+			response.Errors[location] = ErrorResponse{
+				Error:   "MISSING_LOCATION",
+				Code:    http.StatusBadRequest,
+				Message: "Location must not be empty",
+			}
+			continue
+		}
+		locations = append(locations, location)
+	}
+
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_FIELDS", err.Error())
+		return
+	}
+	units, err := parseUnits(strings.ToLower(strings.TrimSpace(r.URL.Query().Get("units"))))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_UNITS", err.Error())
+		return
+	}
+
+	// her farklı lokasyon bir istek sayılır; quota fan-out'tan önce kontrol edilir
+	distinct := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		distinct[location] = true
+	}
+	if h.quota != nil && !h.quota(w, r, len(distinct)) {
+		return
+	}
+
+	results, errs := h.weatherService.GetWeatherBatch(r.Context(), locations)
+	if r.Context().Err() != nil {
+		return
+	}
+
+	for location, weatherResp := range results {
+		result := newWeatherResponse(weatherResp, fields)
+		result.Units = units
+		convertUnits(&result, units)
+		response.Results[location] = result
+	}
+	for location, err := range errs {
+		h.logger.Ctx(r.Context()).Error().
+			Str("component", "handler").
+			Str("action", "batch_location_error").
+			Str("location", location).
+			Err(err).
+			Msg("Weather not fetched for batch location")
+		response.Errors[location] = weatherError(err)
+	}
+
+	h.logger.Ctx(r.Context()).Info().
+		Str("component", "handler").
+		Str("action", "batch_completed").
+		Str("tenant_id", middleware.TenantFromContext(r.Context())).
+		Int("location_count", len(request.Locations)).
+		Int("succeeded", len(response.Results)).
+		Int("failed", len(response.Errors)).
+		Dur("response_time", time.Since(startTime)).
+		Msg("Batch weather request completed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Ctx(r.Context()).Error().
+			Str("component", "handler").
+			Str("action", "json_encode_error").
+			Err(err).
+			Msg("JSON encoding failed")
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goweather/internal/services"
+	"goweather/pkg/types"
+)
+
+type ForecastResponse struct {
+	Location string              `json:"location"`
+	Days     int                 `json:"days"`
+	Units    string              `json:"units"`
+	Forecast []types.ForecastDay `json:"forecast"`
+}
+
+// GetForecast serves /forecast?q=<location>&days=N with daily min/max/avg
+// temperatures averaged across the providers that support forecasts.
+func (h *WeatherHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	location := r.URL.Query().Get("q")
+	if strings.TrimSpace(location) == "" {
+		h.sendError(w, http.StatusBadRequest, "MISSING_LOCATION", "Location parameter 'q' is required")
+		return
+	}
+
+	days := 1
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "INVALID_DAYS", "Parameter 'days' must be a number")
+			return
+		}
+		days = parsed
+	}
+
+	units, err := parseUnits(strings.ToLower(strings.TrimSpace(r.URL.Query().Get("units"))))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_UNITS", err.Error())
+		return
+	}
+
+	forecast, err := h.weatherService.GetForecast(r.Context(), location, days)
+	if r.Context().Err() != nil {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidDays) {
+		h.sendError(w, http.StatusBadRequest, "INVALID_DAYS", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrTooManyGroups) {
+		h.sendError(w, http.StatusServiceUnavailable, "TOO_MANY_LOCATIONS", "Too many locations are being aggregated, retry shortly")
+		return
+	}
+	if err != nil {
+		h.logger.Ctx(r.Context()).Error().
+			Str("component", "handler").
+			Str("action", "forecast_error").
+			Str("location", location).
+			Int("days", days).
+			Err(err).
+			Msg("Forecast not fetched")
+		h.sendError(w, http.StatusInternalServerError, "FORECAST_SERVICE_ERROR", "Failed to fetch forecast data")
+		return
+	}
+
+	response := ForecastResponse{
+		Location: forecast.Location,
+		Days:     forecast.Days,
+		Units:    units,
+		Forecast: make([]types.ForecastDay, len(forecast.Forecast)),
+	}
+	for i, day := range forecast.Forecast {
+		day.MinTemp = convertTemperature(day.MinTemp, units)
+		day.MaxTemp = convertTemperature(day.MaxTemp, units)
+		day.AvgTemp = convertTemperature(day.AvgTemp, units)
+		response.Forecast[i] = day
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Ctx(r.Context()).Error().
+			Str("component", "handler").
+			Str("action", "json_encode_error").
+			Err(err).
+			Msg("JSON encoding failed")
+	}
+}
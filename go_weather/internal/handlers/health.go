@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"goweather/internal/clients"
+	"goweather/internal/database"
+	"goweather/internal/logger"
+	"goweather/internal/services"
+)
+
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+type HealthHandler struct {
+	database       database.Store
+	weatherService *services.WeatherService
+	queueThreshold float64
+	logger         *logger.Logger
+}
+
+type ReadinessResponse struct {
+	Status string          `json:"status"`
+	Checks ReadinessChecks `json:"checks"`
+}
+
+type ReadinessChecks struct {
+	Database   DatabaseCheck   `json:"database"`
+	WriteQueue WriteQueueCheck `json:"write_queue"`
+	Providers  ProvidersCheck  `json:"providers"`
+}
+
+type DatabaseCheck struct {
+	Status string `json:"status"`
+	Driver string `json:"driver"`
+	Error  string `json:"error,omitempty"`
+}
+
+type WriteQueueCheck struct {
+	Status     string  `json:"status"`
+	Depth      int     `json:"depth"`
+	Capacity   int     `json:"capacity"`
+	Saturation float64 `json:"saturation"`
+	Dropped    uint64  `json:"dropped"`
+}
+
+type ProvidersCheck struct {
+	Status   string                   `json:"status"`
+	Healthy  int                      `json:"healthy"`
+	Required int                      `json:"required"`
+	Details  []clients.ProviderStatus `json:"details"`
+}
+
+// NewHealthHandler; queueThreshold is the write queue saturation (0-1) at
+// which the instance reports not ready.
+func NewHealthHandler(db database.Store, weatherService *services.WeatherService, queueThreshold float64) *HealthHandler {
+	return &HealthHandler{
+		database:       db,
+		weatherService: weatherService,
+		queueThreshold: queueThreshold,
+		logger:         logger.Get(),
+	}
+}
+
+// Liveness only proves the process is serving HTTP
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// Readiness checks the database, the write queue and upstream providers
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	response := ReadinessResponse{
+		Status: "ready",
+		Checks: ReadinessChecks{
+			Database:   h.checkDatabase(ctx),
+			WriteQueue: h.checkWriteQueue(),
+			Providers:  h.checkProviders(),
+		},
+	}
+
+	statusCode := http.StatusOK
+	checks := response.Checks
+	if checks.Database.Status != checkOK || checks.WriteQueue.Status != checkOK || checks.Providers.Status != checkOK {
+		response.Status = "not_ready"
+		statusCode = http.StatusServiceUnavailable
+		h.logger.Warn().
+			Str("component", "handler").
+			Str("action", "not_ready").
+			Str("database", checks.Database.Status).
+			Str("write_queue", checks.WriteQueue.Status).
+			Str("providers", checks.Providers.Status).
+			Msg("Readiness check failed")
+	}
+
+	h.writeJSON(w, statusCode, response)
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) DatabaseCheck {
+	if err := h.database.Ping(ctx); err != nil {
+		return DatabaseCheck{Status: checkFail, Driver: h.database.Driver(), Error: err.Error()}
+	}
+	return DatabaseCheck{Status: checkOK, Driver: h.database.Driver()}
+}
+
+func (h *HealthHandler) checkWriteQueue() WriteQueueCheck {
+	stats := h.weatherService.WriterStats()
+	check := WriteQueueCheck{
+		Status:   checkOK,
+		Depth:    stats.QueueDepth,
+		Capacity: stats.QueueCapacity,
+		Dropped:  stats.Dropped,
+	}
+	if stats.QueueCapacity > 0 {
+		check.Saturation = float64(stats.QueueDepth) / float64(stats.QueueCapacity)
+	}
+	if check.Saturation >= h.queueThreshold {
+		check.Status = checkFail
+	}
+	return check
+}
+
+func (h *HealthHandler) checkProviders() ProvidersCheck {
+	statuses := h.weatherService.ProviderStatuses()
+	check := ProvidersCheck{
+		Status:   checkOK,
+		Required: h.weatherService.RequiredProviders(),
+		Details:  statuses,
+	}
+	for _, status := range statuses {
+		if status.Healthy {
+			check.Healthy++
+		}
+	}
+	if check.Healthy < check.Required {
+		check.Status = checkFail
+	}
+	return check
+}
+
+func (h *HealthHandler) writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error().
+			Str("component", "handler").
+			Str("action", "json_encode_error").
+			Err(err).
+			Msg("JSON encoding failed")
+	}
+}
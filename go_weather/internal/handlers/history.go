@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"goweather/internal/database"
+	"goweather/internal/logger"
+	"goweather/internal/middleware"
+	"goweather/internal/services"
+)
+
+// HistoryHandler serves the logged upstream fetches (weather_queries).
+// Tenants see only the fetches that served them; the admin token sees all.
+type HistoryHandler struct {
+	weatherService *services.WeatherService
+	adminToken     string
+	logger         *logger.Logger
+}
+
+func NewHistoryHandler(weatherService *services.WeatherService, adminToken string) *HistoryHandler {
+	return &HistoryHandler{
+		weatherService: weatherService,
+		adminToken:     adminToken,
+		logger:         logger.Get(),
+	}
+}
+
+// Authorize admits the admin bearer token as is and sends every other
+// request through authenticate, which must resolve an API key to a tenant:
+// history is never public, even when AUTH_REQUIRED is off.
+func (h *HistoryHandler) Authorize(authenticate func(http.HandlerFunc) http.HandlerFunc, next http.HandlerFunc) http.HandlerFunc {
+	tenantOnly := authenticate(func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.Authenticated(r.Context()) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="weather"`)
+			writeError(w, h.logger, http.StatusUnauthorized, "UNAUTHORIZED", "API key or admin bearer token required")
+			return
+		}
+		next(w, r)
+	})
+	return func(w http.ResponseWriter, r *http.Request) {
+		if hasAdminToken(r, h.adminToken) {
+			next(w, r)
+			return
+		}
+		tenantOnly(w, r)
+	}
+}
+
+// History serves GET /history?location=&from=&to=&sort=&order=&limit=&cursor=.
+// Pages are newest first unless order=asc; pass next_cursor back as cursor.
+// The admin token may add tenant= to see one tenant's fetches.
+func (h *HistoryHandler) History(w http.ResponseWriter, r *http.Request) {
+	if !h.allowGet(w, r) {
+		return
+	}
+	params := r.URL.Query()
+
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+	query := database.HistoryQuery{
+		HistoryFilter: filter,
+		Sort:          params.Get("sort"),
+		Descending:    true,
+		Cursor:        params.Get("cursor"),
+	}
+	switch strings.ToLower(params.Get("order")) {
+	case "", "desc":
+	case "asc":
+		query.Descending = false
+	default:
+		writeError(w, h.logger, http.StatusBadRequest, "INVALID_ORDER", "Parameter 'order' must be asc or desc")
+		return
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, h.logger, http.StatusBadRequest, "INVALID_LIMIT", "Parameter 'limit' must be a positive number")
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := h.weatherService.History(r.Context(), query)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, h.logger, http.StatusOK, page)
+}
+
+// Stats serves GET /history/stats?location=&from=&to=, scoped like History.
+func (h *HistoryHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	if !h.allowGet(w, r) {
+		return
+	}
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.weatherService.HistoryStats(r.Context(), filter)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, h.logger, http.StatusOK, stats)
+}
+
+func (h *HistoryHandler) allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodGet)
+	writeError(w, h.logger, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Use GET")
+	return false
+}
+
+func (h *HistoryHandler) parseFilter(w http.ResponseWriter, r *http.Request) (database.HistoryFilter, bool) {
+	params := r.URL.Query()
+	filter := database.HistoryFilter{Location: params.Get("location")}
+	if hasAdminToken(r, h.adminToken) {
+		filter.TenantID = params.Get("tenant")
+	} else {
+		filter.TenantID = middleware.TenantFromContext(r.Context())
+	}
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := params.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseTimeParam(value)
+		if err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "INVALID_TIME_RANGE",
+				fmt.Sprintf("Parameter '%s' must be RFC 3339 or YYYY-MM-DD", bound.name))
+			return filter, false
+		}
+		*bound.target = parsed
+	}
+	return filter, true
+}
+
+// parseTimeParam accepts RFC 3339 timestamps and plain UTC dates
+func parseTimeParam(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func (h *HistoryHandler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrInvalidHistoryQuery) {
+		writeError(w, h.logger, http.StatusBadRequest, "INVALID_HISTORY_QUERY", err.Error())
+		return
+	}
+	h.logger.Ctx(r.Context()).Error().
+		Str("component", "handler").
+		Str("action", "history_error").
+		Str("query", r.URL.RawQuery).
+		Err(err).
+		Msg("History query failed")
+	writeError(w, h.logger, http.StatusInternalServerError, "HISTORY_ERROR", "Failed to read query history")
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import "fmt"
+
+// Units systems /weather can answer in. Aggregation and the cache always
+// work in metric; conversion happens only when the response is written.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	UnitsKelvin   = "kelvin"
+)
+
+func parseUnits(value string) (string, error) {
+	switch value {
+	case "", UnitsMetric:
+		return UnitsMetric, nil
+	case UnitsImperial, UnitsKelvin:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown units %q, expected metric, imperial or kelvin", value)
+	}
+}
+
+// convertUnits rewrites a metric response in place. Imperial switches
+// temperatures to °F, wind to mph and pressure to inHg; kelvin only
+// changes temperatures.
+func convertUnits(response *WeatherResponse, units string) {
+	switch units {
+	case UnitsImperial:
+		response.Temperature = celsiusToFahrenheit(response.Temperature)
+		response.FeelsLike = convertOptional(response.FeelsLike, celsiusToFahrenheit)
+		response.WindSpeed = convertOptional(response.WindSpeed, kphToMph)
+		response.Pressure = convertOptional(response.Pressure, mbToInHg)
+	case UnitsKelvin:
+		response.Temperature = celsiusToKelvin(response.Temperature)
+		response.FeelsLike = convertOptional(response.FeelsLike, celsiusToKelvin)
+	}
+}
+
+// convertTemperature converts a °C value to the temperature unit of units
+func convertTemperature(c float64, units string) float64 {
+	switch units {
+	case UnitsImperial:
+		return celsiusToFahrenheit(c)
+	case UnitsKelvin:
+		return celsiusToKelvin(c)
+	default:
+		return c
+	}
+}
+
+func convertOptional(value *float64, convert func(float64) float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	converted := convert(*value)
+	return &converted
+}
+
+func celsiusToFahrenheit(c float64) float64 { return c*9/5 + 32 }
+
+func celsiusToKelvin(c float64) float64 { return c + 273.15 }
+
+func kphToMph(kph float64) float64 { return kph / 1.609344 }
+
+func mbToInHg(mb float64) float64 { return mb * 0.0295299830714 }
@@ -2,15 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"goweather/internal/logger"
+	"goweather/internal/middleware"
 	"goweather/internal/services"
+	"goweather/pkg/types"
 )
 
+// QuotaFunc charges requests against the caller's quota. It writes the
+// rejection itself and returns false when the request must stop.
+type QuotaFunc func(w http.ResponseWriter, r *http.Request, requests int) bool
+
 type WeatherHandler struct {
 	weatherService *services.WeatherService
+	quota          QuotaFunc // charges /weather/batch per location; nil charges nothing
 	logger         *logger.Logger
 }
 
@@ -21,55 +32,173 @@ type ErrorResponse struct {
 }
 
 type WeatherResponse struct {
-	Location    string  `json:"location"`
-	Temperature float64 `json:"temperature"`
+	Location      string     `json:"location"`
+	Temperature   float64    `json:"temperature"`
+	Units         string     `json:"units"`
+	FeelsLike     *float64   `json:"feels_like,omitempty"`
+	Humidity      *float64   `json:"humidity,omitempty"`
+	WindSpeed     *float64   `json:"wind_speed,omitempty"`
+	WindDirection *float64   `json:"wind_direction,omitempty"`
+	Pressure      *float64   `json:"pressure,omitempty"`
+	Condition     string     `json:"condition,omitempty"`
+	ConditionCode *int       `json:"condition_code,omitempty"`
+	ObservedAt    *time.Time `json:"observed_at,omitempty"`
+}
+
+// weatherFields are the optional values /weather can add with fields=
+var weatherFields = []string{"feels_like", "humidity", "wind_speed", "wind_direction", "pressure", "condition", "condition_code", "observed_at"}
+
+// parseFields reads fields=a,b,c; "all" selects every optional field.
+func parseFields(value string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		switch {
+		case field == "":
+			continue
+		case field == "all":
+			for _, f := range weatherFields {
+				selected[f] = true
+			}
+		case slices.Contains(weatherFields, field):
+			selected[field] = true
+		default:
+			return nil, fmt.Errorf("unknown field %q, expected one of: all, %s", field, strings.Join(weatherFields, ", "))
+		}
+	}
+	return selected, nil
+}
+
+func newWeatherResponse(weatherResp *types.WeatherResponse, fields map[string]bool) WeatherResponse {
+	conditions := weatherResp.Conditions
+	response := WeatherResponse{
+		Location:    weatherResp.Location,
+		Temperature: weatherResp.Temperature,
+	}
+	if fields["feels_like"] {
+		response.FeelsLike = conditions.FeelsLike
+	}
+	if fields["humidity"] {
+		response.Humidity = conditions.Humidity
+	}
+	if fields["wind_speed"] {
+		response.WindSpeed = conditions.WindSpeed
+	}
+	if fields["wind_direction"] {
+		response.WindDirection = conditions.WindDirection
+	}
+	if fields["pressure"] {
+		response.Pressure = conditions.Pressure
+	}
+	if fields["condition"] {
+		response.Condition = conditions.ConditionText
+	}
+	if fields["condition_code"] {
+		response.ConditionCode = conditions.ConditionCode
+	}
+	if fields["observed_at"] {
+		response.ObservedAt = conditions.ObservedAt
+	}
+	return response
 }
 
-func NewWeatherHandler(weatherService *services.WeatherService) *WeatherHandler {
+func NewWeatherHandler(weatherService *services.WeatherService, quota QuotaFunc) *WeatherHandler {
 	return &WeatherHandler{
 		weatherService: weatherService,
+		quota:          quota,
 		logger:         logger.Get(),
 	}
 }
 
 func (h *WeatherHandler) GetWeather(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	// Generate a simple user ID for demo purposes (in real app, this would come from auth)
-	userID := 123
+	// Tenant, API key middleware'inden gelir; key'siz isteklerde "anonymous"
+	tenantID := middleware.TenantFromContext(r.Context())
+	log := h.logger.Ctx(r.Context())
 	
 	// Query parameter kontrolü
 	location := r.URL.Query().Get("q")
-	if location == "" {
-		h.logger.WeatherError(location, userID, nil, time.Since(startTime))
+	if strings.TrimSpace(location) == "" {
+		log.WeatherError(location, tenantID, nil, time.Since(startTime))
 		h.sendError(w, http.StatusBadRequest, "MISSING_LOCATION", "Location parameter 'q' is required")
 		return
 	}
 
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_FIELDS", err.Error())
+		return
+	}
+
+	units, err := parseUnits(strings.ToLower(strings.TrimSpace(r.URL.Query().Get("units"))))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_UNITS", err.Error())
+		return
+	}
+
 	// Log the weather request (similar to Pino example)
-	h.logger.WeatherRequest(location, userID).Msg("User requested weather")
+	log.WeatherRequest(location, tenantID).Msg("User requested weather")
 
 	// Weather service çağrısı
-	weatherResp, err := h.weatherService.GetWeather(location)
+	weatherResp, err := h.weatherService.GetWeather(r.Context(), location)
 	responseTime := time.Since(startTime)
 	
+	// Client gave up; nobody is left to write a response to
+	if r.Context().Err() != nil {
+		log.WeatherCancelled(location, tenantID, responseTime)
+		return
+	}
+
 	if err != nil {
-		h.logger.WeatherError(location, userID, err, responseTime)
-		h.sendError(w, http.StatusInternalServerError, "WEATHER_SERVICE_ERROR", "Failed to fetch weather data")
+		log.WeatherError(location, tenantID, err, responseTime)
+		errorResp := weatherError(err)
+		h.sendError(w, errorResp.Code, errorResp.Error, errorResp.Message)
 		return
 	}
 
 	// Başarılı response - structured logging like Pino
-	h.logger.WeatherCompleted(location, userID, responseTime, weatherResp.Temperature, 1)
+	log.WeatherCompleted(location, tenantID, responseTime, weatherResp.Temperature, 1)
 
-	response := WeatherResponse{
-		Location:    weatherResp.Location,
-		Temperature: weatherResp.Temperature,
-	}
+	response := newWeatherResponse(weatherResp, fields)
+	response.Units = units
+	convertUnits(&response, units)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	
 	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error().
+			Str("component", "handler").
+			Str("action", "json_encode_error").
+			Err(err).
+			Msg("JSON encoding failed")
+	}
+}
+
+// weatherError is the response for a failed weather fetch, shared by
+// /weather and the per-location errors of /weather/batch: overload is 503
+// so clients retry, anything else an upstream failure.
+func weatherError(err error) ErrorResponse {
+	if errors.Is(err, services.ErrTooManyGroups) {
+		return ErrorResponse{
+			Error:   "TOO_MANY_LOCATIONS",
+			Code:    http.StatusServiceUnavailable,
+			Message: "Too many locations are being aggregated, retry shortly",
+		}
+	}
+	return ErrorResponse{
+		Error:   "WEATHER_SERVICE_ERROR",
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to fetch weather data",
+	}
+}
+
+// GetProviderStatus reports per-provider health and circuit breaker state
+func (h *WeatherHandler) GetProviderStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(h.weatherService.ProviderStatuses()); err != nil {
 		h.logger.Error().
 			Str("component", "handler").
 			Str("action", "json_encode_error").
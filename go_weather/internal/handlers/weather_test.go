@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"goweather/internal/services"
+)
+
+func TestWeatherError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		want string
+	}{
+		{"group cap", services.ErrTooManyGroups, http.StatusServiceUnavailable, "TOO_MANY_LOCATIONS"},
+		{"wrapped group cap", fmt.Errorf("istanbul: %w", services.ErrTooManyGroups), http.StatusServiceUnavailable, "TOO_MANY_LOCATIONS"},
+		{"provider failure", errors.New("provider quorum not met"), http.StatusInternalServerError, "WEATHER_SERVICE_ERROR"},
+	}
+	for _, tt := range tests {
+		got := weatherError(tt.err)
+		if got.Code != tt.code || got.Error != tt.want {
+			t.Errorf("%s: weatherError = %d %s, want %d %s", tt.name, got.Code, got.Error, tt.code, tt.want)
+		}
+	}
+}
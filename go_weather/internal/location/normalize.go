@@ -0,0 +1,88 @@
+package location
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultAliases maps historical or alternative names to the name upstream
+// providers know. Keys and values are normalized before use.
+var DefaultAliases = map[string]string{
+	"Constantinople": "Istanbul",
+	"Byzantium":      "Istanbul",
+	"Angora":         "Ankara",
+	"Smyrna":         "Izmir",
+}
+
+// Letters that have no decomposed form, so stripping combining marks alone
+// does not reduce them to ASCII.
+var letterReplacer = strings.NewReplacer(
+	"ı", "i",
+	"ø", "o",
+	"æ", "ae",
+	"œ", "oe",
+	"đ", "d",
+	"ł", "l",
+	"þ", "th",
+)
+
+var coordinatePattern = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)$`)
+
+// Normalizer turns user supplied locations into canonical grouping keys so
+// "Istanbul", " istanbul ", "İstanbul" and "Constantinople" share one key.
+type Normalizer struct {
+	aliases        map[string]string
+	coordPrecision int
+}
+
+// NewNormalizer builds a normalizer; coordPrecision is the number of decimals
+// kept for "lat,lon" queries.
+func NewNormalizer(aliases map[string]string, coordPrecision int) *Normalizer {
+	n := &Normalizer{
+		aliases:        make(map[string]string, len(aliases)),
+		coordPrecision: max(coordPrecision, 0),
+	}
+	for from, to := range aliases {
+		n.aliases[fold(from)] = fold(to)
+	}
+	return n
+}
+
+// Key returns the canonical form of q; an empty result means q had no content.
+func (n *Normalizer) Key(q string) string {
+	q = strings.Join(strings.Fields(q), " ")
+
+	if match := coordinatePattern.FindStringSubmatch(q); match != nil {
+		lat, latErr := strconv.ParseFloat(match[1], 64)
+		lon, lonErr := strconv.ParseFloat(match[2], 64)
+		if latErr == nil && lonErr == nil {
+			return fmt.Sprintf("%.*f,%.*f", n.coordPrecision, lat, n.coordPrecision, lon)
+		}
+	}
+
+	key := fold(q)
+	if alias, ok := n.aliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// fold applies Unicode case folding and strips diacritics.
+func fold(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = cases.Fold().String(s)
+
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if stripped, _, err := transform.String(stripMarks, s); err == nil {
+		s = stripped
+	}
+	return letterReplacer.Replace(s)
+}
@@ -0,0 +1,74 @@
+package location
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	n := NewNormalizer(DefaultAliases, 2)
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"Istanbul", "istanbul"},
+		{"  istanbul  ", "istanbul"},
+		{"ISTANBUL", "istanbul"},
+		{"İstanbul", "istanbul"},
+		{"İSTANBUL", "istanbul"},
+		{"ıstanbul", "istanbul"},
+		{"Ağrı", "agri"},
+		{"São   Paulo", "sao paulo"},
+		{"Straße", "strasse"},
+		{"Łódź", "lodz"},
+		{"Constantinople", "istanbul"},
+		{"constantinople ", "istanbul"},
+		{"Smyrna", "izmir"},
+		{"Byzantium Road", "byzantium road"},
+		{"41.0082,28.9784", "41.01,28.98"},
+		{" 41.0082 , 28.9784 ", "41.01,28.98"},
+		{"41.01,28.98", "41.01,28.98"},
+		{"-33.8688,151.2093", "-33.87,151.21"},
+		{"41,29", "41.00,29.00"},
+		{"41.0082;28.9784", "41.0082;28.9784"},
+		{"   ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := n.Key(tt.q); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestKeyCoordinatePrecision(t *testing.T) {
+	tests := []struct {
+		precision int
+		want      string
+	}{
+		{0, "41,29"},
+		{-1, "41,29"},
+		{1, "41.0,29.0"},
+		{4, "41.0082,28.9784"},
+	}
+	for _, tt := range tests {
+		n := NewNormalizer(nil, tt.precision)
+		if got := n.Key("41.0082,28.9784"); got != tt.want {
+			t.Errorf("precision %d: Key = %q, want %q", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestKeyCustomAliases(t *testing.T) {
+	n := NewNormalizer(map[string]string{"Stamboul": "İstanbul", "The Big Apple": "New York"}, 2)
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"stamboul", "istanbul"},
+		{"the  big APPLE", "new york"},
+		{"Constantinople", "constantinople"}, // defaults aren't merged in
+	}
+	for _, tt := range tests {
+		if got := n.Key(tt.q); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger wraps zerolog.Logger with additional context methods
@@ -60,38 +62,102 @@ func SetGlobal(l *Logger) {
 	globalLogger = l
 }
 
+type requestIDKey struct{}
+type requestIDsKey struct{}
+
+// WithRequestID returns ctx carrying the ID of the HTTP request it serves
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID set by WithRequestID, or ""
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithRequestIDs returns ctx for work done on behalf of several requests,
+// such as an aggregation batch.
+func WithRequestIDs(ctx context.Context, requestIDs []string) context.Context {
+	return context.WithValue(ctx, requestIDsKey{}, requestIDs)
+}
+
+// Ctx returns l with the request ID(s) and the trace and span IDs found in
+// ctx added to every line, so logs of one request can be correlated across
+// handler, aggregation, provider calls and database writes.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+	requestID := RequestID(ctx)
+	requestIDs, _ := ctx.Value(requestIDsKey{}).([]string)
+	spanContext := trace.SpanContextFromContext(ctx)
+	if requestID == "" && len(requestIDs) == 0 && !spanContext.IsValid() {
+		return l
+	}
+
+	fields := l.With()
+	if requestID != "" {
+		fields = fields.Str("request_id", requestID)
+	}
+	if len(requestIDs) > 0 {
+		fields = fields.Strs("request_ids", requestIDs)
+	}
+	if spanContext.IsValid() {
+		fields = fields.
+			Str("trace_id", spanContext.TraceID().String()).
+			Str("span_id", spanContext.SpanID().String())
+	}
+	return &Logger{Logger: fields.Logger()}
+}
+
+// Ctx is Get().Ctx(ctx)
+func Ctx(ctx context.Context) *Logger {
+	return globalLogger.Ctx(ctx)
+}
+
 // Weather request logging methods (similar to Pino structured logging)
-func (l *Logger) WeatherRequest(location string, userID int) *zerolog.Event {
+func (l *Logger) WeatherRequest(location string, tenantID string) *zerolog.Event {
 	return l.Info().
 		Str("component", "weather").
 		Str("action", "request").
 		Str("location", location).
-		Int("user_id", userID)
+		Str("tenant_id", tenantID)
 }
 
-func (l *Logger) WeatherCompleted(location string, userID int, responseTime time.Duration, temperature float64, requestCount int) {
+func (l *Logger) WeatherCompleted(location string, tenantID string, responseTime time.Duration, temperature float64, requestCount int) {
 	l.Info().
 		Str("component", "weather").
 		Str("action", "completed").
 		Str("location", location).
-		Int("user_id", userID).
+		Str("tenant_id", tenantID).
 		Dur("response_time", responseTime).
 		Float64("temperature", temperature).
 		Int("request_count", requestCount).
 		Msg("Weather request completed")
 }
 
-func (l *Logger) WeatherError(location string, userID int, err error, responseTime time.Duration) {
+func (l *Logger) WeatherError(location string, tenantID string, err error, responseTime time.Duration) {
 	l.Error().
 		Str("component", "weather").
 		Str("action", "error").
 		Str("location", location).
-		Int("user_id", userID).
+		Str("tenant_id", tenantID).
 		Dur("response_time", responseTime).
 		Err(err).
 		Msg("Weather request failed")
 }
 
+func (l *Logger) WeatherCancelled(location string, tenantID string, responseTime time.Duration) {
+	l.Info().
+		Str("component", "weather").
+		Str("action", "cancelled").
+		Str("location", location).
+		Str("tenant_id", tenantID).
+		Dur("response_time", responseTime).
+		Msg("Client disconnected before weather response")
+}
+
 // API client logging methods
 func (l *Logger) APIRequest(service, location, url string) *zerolog.Event {
 	return l.Debug().
@@ -124,6 +190,33 @@ func (l *Logger) APIError(service, location string, err error, responseTime time
 		Msg("API request failed")
 }
 
+func (l *Logger) APIRetry(service, location string, attempt int, delay time.Duration, reason string) {
+	l.Warn().
+		Str("component", "api_client").
+		Str("action", "retry").
+		Str("service", service).
+		Str("location", location).
+		Int("attempt", attempt).
+		Dur("delay", delay).
+		Str("reason", reason).
+		Msg("Retrying API request")
+}
+
+func (l *Logger) CircuitStateChanged(service, from, to string, failures int) {
+	event := l.Info()
+	if to == "open" {
+		event = l.Warn()
+	}
+	event.
+		Str("component", "api_client").
+		Str("action", "circuit_state_changed").
+		Str("service", service).
+		Str("from", from).
+		Str("to", to).
+		Int("consecutive_failures", failures).
+		Msg("Circuit breaker state changed")
+}
+
 // Aggregation logging methods
 func (l *Logger) AggregationGroupCreated(location string) {
 	l.Debug().
@@ -170,18 +263,83 @@ func (l *Logger) AggregationProcessing(location string, requestCount int) {
 		Msg("Processing aggregated requests")
 }
 
-// Database logging methods
-func (l *Logger) DatabaseSave(location string, service1Temp, service2Temp float64, requestCount int) {
+func (l *Logger) AggregationRequestLeft(location string, remaining int) {
+	l.Debug().
+		Str("component", "aggregation").
+		Str("action", "request_left").
+		Str("location", location).
+		Int("remaining_requests", remaining).
+		Msg("Caller left aggregation group before processing")
+}
+
+func (l *Logger) AggregationBatchCancelled(location string, requestCount int) {
+	l.Info().
+		Str("component", "aggregation").
+		Str("action", "batch_cancelled").
+		Str("location", location).
+		Int("request_count", requestCount).
+		Msg("All callers left, batch cancelled")
+}
+
+func (l *Logger) AggregationFlushed(groupCount int) {
+	l.Info().
+		Str("component", "aggregation").
+		Str("action", "flushed").
+		Int("group_count", groupCount).
+		Msg("Pending aggregation groups flushed")
+}
+
+func (l *Logger) AggregationProviderFailed(location, provider string, err error) {
+	l.Warn().
+		Str("component", "aggregation").
+		Str("action", "provider_failed").
+		Str("location", location).
+		Str("service", provider).
+		Err(err).
+		Msg("Provider failed, averaging remaining providers")
+}
+
+// Cache logging methods
+func (l *Logger) CacheHit(location string) {
 	l.Debug().
+		Str("component", "cache").
+		Str("action", "hit").
+		Str("location", location).
+		Msg("Weather response served from cache")
+}
+
+// Database logging methods
+func (l *Logger) DatabaseSave(location string, service1Temp, service2Temp *float64, requestCount int) {
+	event := l.Debug().
 		Str("component", "database").
 		Str("action", "save").
-		Str("location", location).
-		Float64("service1_temp", service1Temp).
-		Float64("service2_temp", service2Temp).
+		Str("location", location)
+	optionalFloat(event, "service1_temp", service1Temp)
+	optionalFloat(event, "service2_temp", service2Temp)
+	event.
 		Int("request_count", requestCount).
 		Msg("Weather data saved to database")
 }
 
+func (l *Logger) DatabaseBatchSaved(rowCount int, duration time.Duration) {
+	l.Debug().
+		Str("component", "database").
+		Str("action", "batch_saved").
+		Int("row_count", rowCount).
+		Dur("duration", duration).
+		Msg("Weather query batch written")
+}
+
+func (l *Logger) DatabaseWriteDropped(location, reason string, queueDepth int) {
+	l.Warn().
+		Str("component", "database").
+		Str("action", "write_dropped").
+		Str("location", location).
+		Str("reason", reason).
+		Int("queue_depth", queueDepth).
+		Msg("Weather query dropped before reaching the database")
+}
+
 func (l *Logger) DatabaseError(operation string, err error) {
 	l.Error().
 		Str("component", "database").
@@ -207,6 +365,14 @@ func (l *Logger) ServerShutdown() {
 		Msg("Server shutting down")
 }
 
+// optionalFloat writes null for a missing value instead of a misleading 0
+func optionalFloat(event *zerolog.Event, key string, value *float64) *zerolog.Event {
+	if value == nil {
+		return event.Interface(key, nil)
+	}
+	return event.Float64(key, *value)
+}
+
 // Convenience methods for backward compatibility
 func Info() *zerolog.Event {
 	return globalLogger.Info()
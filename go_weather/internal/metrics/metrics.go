@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goweather"
+
+// Registry holds every goweather metric plus the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Request metrics
+var (
+	// Requests counts /weather calls by location bucket and result
+	// (cache_hit, success, error, cancelled).
+	Requests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Weather requests by location bucket and result.",
+	}, []string{"location_bucket", "result"})
+
+	// RateLimited counts requests rejected with 429, by how the caller was keyed (ip, tenant).
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter.",
+	}, []string{"key_type"})
+
+	// TenantRequests counts authenticated requests per tenant, for usage billing.
+	TenantRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tenant_requests_total",
+		Help:      "Requests admitted per tenant.",
+	}, []string{"tenant"})
+
+	// QuotaExceeded counts requests rejected by a tenant quota (daily, monthly).
+	QuotaExceeded = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_exceeded_total",
+		Help:      "Requests rejected because the tenant quota was used up.",
+	}, []string{"tenant", "period"})
+)
+
+// Aggregation metrics
+var (
+	BatchSize = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "batch_size",
+		Help:      "Requests served by a single upstream fan-out.",
+		Buckets:   []float64{1, 2, 3, 5, 8, 10, 15, 20, 50},
+	})
+
+	// Batches counts fan-outs by what fired them (timer, max_reached, flush).
+	Batches = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "batches_total",
+		Help:      "Aggregation batches by trigger.",
+	}, []string{"trigger"})
+
+	WaitTime = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "wait_seconds",
+		Help:      "Time a request spent in its aggregation group before the batch fired.",
+		Buckets:   []float64{0.01, 0.1, 0.5, 1, 2, 3, 4, 5, 6, 10},
+	})
+
+	Groups = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "groups",
+		Help:      "Aggregation groups currently held in memory.",
+	})
+
+	// GroupEvictions counts removed groups by reason (idle, overflow, admin).
+	GroupEvictions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "group_evictions_total",
+		Help:      "Aggregation groups removed, by reason.",
+	}, []string{"reason"})
+
+	// GroupOverflows counts requests that found MAX_GROUPS reached, by the
+	// overflow behavior applied (reject, direct, evict).
+	GroupOverflows = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "aggregation",
+		Name:      "group_overflows_total",
+		Help:      "Requests arriving while the aggregation group cap was reached.",
+	}, []string{"behavior"})
+)
+
+// Provider metrics
+var (
+	ProviderLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "request_duration_seconds",
+		Help:      "Upstream provider call latency, including retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider"})
+
+	ProviderErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "errors_total",
+		Help:      "Failed upstream provider calls, not counting calls cancelled because every caller left.",
+	}, []string{"provider"})
+)
+
+// Database metrics
+var (
+	DBWriteDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "write_duration_seconds",
+		Help:      "Duration of a batched weather_queries insert transaction.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// DBRows counts rows by outcome (written, dropped, failed).
+	DBRows = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "rows_total",
+		Help:      "weather_queries rows by write outcome.",
+	}, []string{"result"})
+
+	DBQueueDepth = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "queue_depth",
+		Help:      "Rows waiting in the database write queue.",
+	})
+)
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goweather/internal/cache"
+	"goweather/internal/database"
+	"goweather/internal/logger"
+	"goweather/internal/metrics"
+	"goweather/pkg/types"
+)
+
+// AnonymousTenant is the tenant of requests without an API key when keys
+// aren't required.
+const AnonymousTenant = "anonymous"
+
+// AuthConfig configures API key authentication.
+type AuthConfig struct {
+	Required bool          // reject requests without an API key
+	CacheTTL time.Duration // how long a resolved key is reused before it is looked up again
+}
+
+type tenantKey struct{}
+
+// tenantRecordKey carries the *types.Tenant a valid API key resolved to
+type tenantRecordKey struct{}
+
+// WithTenant returns ctx carrying the tenant ID of the caller.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// authenticatedTenant returns the tenant resolved by Authenticate, or nil
+// for requests without an API key.
+func authenticatedTenant(ctx context.Context) *types.Tenant {
+	tenant, _ := ctx.Value(tenantRecordKey{}).(*types.Tenant)
+	return tenant
+}
+
+// Authenticated reports whether Authenticate resolved an API key for the
+// request, as opposed to letting it through as AnonymousTenant.
+func Authenticated(ctx context.Context) bool {
+	return authenticatedTenant(ctx) != nil
+}
+
+// TenantFromContext returns the tenant set by Authenticate, or
+// AnonymousTenant when there is none.
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok {
+		return tenantID
+	}
+	return AnonymousTenant
+}
+
+// TenantStore is the part of the database the Authenticator needs.
+type TenantStore interface {
+	// TenantByAPIKey returns database.ErrAPIKeyNotFound for unknown or revoked keys.
+	TenantByAPIKey(ctx context.Context, key string) (*types.Tenant, error)
+	// ConsumeQuota adds requests to the tenant's usage, or returns
+	// database.ErrDailyQuotaExceeded / ErrMonthlyQuotaExceeded without counting them.
+	ConsumeQuota(ctx context.Context, tenant *types.Tenant, now time.Time, requests int) (types.TenantUsage, error)
+}
+
+// Authenticator resolves the API key of a request to its tenant and counts
+// the request against the tenant's daily and monthly quotas.
+type Authenticator struct {
+	db      TenantStore
+	config  AuthConfig
+	tenants *cache.Cache[*types.Tenant] // by key hash
+	unknown *cache.Cache[struct{}]      // key hashes that didn't resolve
+	logger  *logger.Logger
+}
+
+func NewAuthenticator(db TenantStore, cfg AuthConfig) *Authenticator {
+	return &Authenticator{
+		db:      db,
+		config:  cfg,
+		tenants: cache.New[*types.Tenant](cfg.CacheTTL, 10000),
+		unknown: cache.New[struct{}](cfg.CacheTTL, 10000),
+		logger:  logger.Get(),
+	}
+}
+
+// Authenticate resolves the API key to its tenant before anything else
+// runs, so the rate limiter can key by tenant and Quota knows whom to
+// charge. Invalid or revoked keys always get 401.
+func (a *Authenticator) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := APIKey(r)
+		if key == "" {
+			if a.config.Required {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="weather"`)
+				writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "API key required in X-API-Key or Authorization: Bearer")
+				return
+			}
+			next(w, r.WithContext(WithTenant(r.Context(), AnonymousTenant)))
+			return
+		}
+
+		tenant, err := a.resolve(r.Context(), key)
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			a.logger.Ctx(r.Context()).Warn().
+				Str("component", "auth").
+				Str("action", "invalid_key").
+				Str("path", r.URL.Path).
+				Str("remote_addr", r.RemoteAddr).
+				Msg("Request with unknown or revoked API key")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="weather", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key is invalid or revoked")
+			return
+		}
+		if err != nil {
+			a.unavailable(w, r, err)
+			return
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("tenant.id", tenant.ID))
+		ctx := context.WithValue(WithTenant(r.Context(), tenant.ID), tenantRecordKey{}, tenant)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// Quota counts the request once against the daily and monthly quotas of
+// the tenant Authenticate resolved. Wrap it inside the rate limiter so
+// rejected requests aren't billed. Endpoints whose cost depends on the body,
+// like /weather/batch, call Charge themselves instead.
+func (a *Authenticator) Quota(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.Charge(w, r, 1) {
+			next(w, r)
+		}
+	}
+}
+
+// Charge counts requests against the quotas of the caller's tenant and
+// reports whether the request may go on; requests without a key pass
+// uncounted. Over quota it answers 429 with Retry-After set to the start
+// of the next UTC day or month, and nothing is counted.
+func (a *Authenticator) Charge(w http.ResponseWriter, r *http.Request, requests int) bool {
+	tenant := authenticatedTenant(r.Context())
+	if tenant == nil || requests <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	usage, err := a.db.ConsumeQuota(r.Context(), tenant, now, requests)
+	switch {
+	case errors.Is(err, database.ErrDailyQuotaExceeded):
+		setQuotaHeaders(w, tenant, usage)
+		a.rejectQuota(w, r, tenant, "daily", nextDay(now).Sub(now))
+		return false
+	case errors.Is(err, database.ErrMonthlyQuotaExceeded):
+		setQuotaHeaders(w, tenant, usage)
+		a.rejectQuota(w, r, tenant, "monthly", nextMonth(now).Sub(now))
+		return false
+	case err != nil:
+		a.unavailable(w, r, err)
+		return false
+	}
+
+	setQuotaHeaders(w, tenant, usage)
+	metrics.TenantRequests.WithLabelValues(tenant.ID).Add(float64(requests))
+	return true
+}
+
+// resolve looks the key up, caching hits so most requests skip the
+// database; revocations and quota changes apply within CacheTTL. Misses
+// are cached too, so made-up keys can't each cost a lookup.
+func (a *Authenticator) resolve(ctx context.Context, key string) (*types.Tenant, error) {
+	hash := database.HashAPIKey(key)
+	if tenant, ok := a.tenants.Get(hash); ok {
+		return tenant, nil
+	}
+	if _, ok := a.unknown.Get(hash); ok {
+		return nil, database.ErrAPIKeyNotFound
+	}
+	tenant, err := a.db.TenantByAPIKey(ctx, key)
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		a.unknown.Set(hash, struct{}{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.tenants.Set(hash, tenant)
+	return tenant, nil
+}
+
+func (a *Authenticator) rejectQuota(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, period string, wait time.Duration) {
+	metrics.QuotaExceeded.WithLabelValues(tenant.ID, period).Inc()
+	a.logger.Ctx(r.Context()).Warn().
+		Str("component", "auth").
+		Str("action", "quota_exceeded").
+		Str("tenant_id", tenant.ID).
+		Str("period", period).
+		Str("path", r.URL.Path).
+		Msg("Tenant quota exceeded")
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(wait), 1)))
+	writeError(w, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "The "+period+" request quota of this API key's tenant is used up")
+}
+
+// unavailable fails closed: without the tenant or its usage the request
+// can be neither attributed nor limited.
+func (a *Authenticator) unavailable(w http.ResponseWriter, r *http.Request, err error) {
+	a.logger.Ctx(r.Context()).Error().
+		Str("component", "auth").
+		Str("action", "lookup_failed").
+		Str("path", r.URL.Path).
+		Err(err).
+		Msg("API key or quota lookup failed")
+	writeError(w, http.StatusServiceUnavailable, "AUTH_UNAVAILABLE", "Authentication is temporarily unavailable")
+}
+
+// setQuotaHeaders reports the remaining requests of each quota the tenant has.
+func setQuotaHeaders(w http.ResponseWriter, tenant *types.Tenant, usage types.TenantUsage) {
+	if tenant.DailyQuota > 0 {
+		w.Header().Set("X-Quota-Daily-Limit", strconv.Itoa(tenant.DailyQuota))
+		w.Header().Set("X-Quota-Daily-Remaining", strconv.Itoa(max(tenant.DailyQuota-usage.DayCount, 0)))
+	}
+	if tenant.MonthlyQuota > 0 {
+		w.Header().Set("X-Quota-Monthly-Limit", strconv.Itoa(tenant.MonthlyQuota))
+		w.Header().Set("X-Quota-Monthly-Remaining", strconv.Itoa(max(tenant.MonthlyQuota-usage.MonthCount, 0)))
+	}
+}
+
+func nextDay(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
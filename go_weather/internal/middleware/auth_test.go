@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"goweather/internal/database"
+	"goweather/pkg/types"
+)
+
+// tenantDB is a SQLite database in a temp dir with tenant acme, quota
+// daily requests a day, and one API key for it.
+func tenantDB(t *testing.T, daily int) (*database.Database, string, *database.APIKey) {
+	t.Helper()
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "weather.sqlite"))
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.CreateTenant(context.Background(), &types.Tenant{ID: "acme", DailyQuota: daily}); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+	key, stored, err := db.CreateAPIKey(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return db, key, stored
+}
+
+// serve runs one request with key through handler and decodes the error
+// body, if any.
+func serve(handler http.HandlerFunc, key string) (*httptest.ResponseRecorder, errorResponse) {
+	r := httptest.NewRequest(http.MethodGet, "/weather?q=istanbul", nil)
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	var body errorResponse
+	if w.Code != http.StatusOK {
+		json.NewDecoder(w.Body).Decode(&body)
+	}
+	return w, body
+}
+
+func TestAuthenticate(t *testing.T) {
+	db, key, _ := tenantDB(t, 0)
+	tests := []struct {
+		name     string
+		required bool
+		key      string
+		status   int
+		code     string
+		tenant   string
+	}{
+		{"valid key", true, key, http.StatusOK, "", "acme"},
+		{"bearer token", true, "bearer", http.StatusOK, "", "acme"},
+		{"unknown key", false, key + "0", http.StatusUnauthorized, "INVALID_API_KEY", ""},
+		{"missing key when required", true, "", http.StatusUnauthorized, "UNAUTHORIZED", ""},
+		{"missing key when optional", false, "", http.StatusOK, "", AnonymousTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := NewAuthenticator(db, AuthConfig{Required: tt.required, CacheTTL: time.Minute})
+			var tenant string
+			handler := authenticator.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+				tenant = TenantFromContext(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/weather?q=istanbul", nil)
+			switch tt.key {
+			case "bearer":
+				r.Header.Set("Authorization", "Bearer "+key)
+			case "":
+			default:
+				r.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body errorResponse
+			json.NewDecoder(w.Body).Decode(&body)
+			if body.Error != tt.code {
+				t.Errorf("error = %q, want %q", body.Error, tt.code)
+			}
+			if tenant != tt.tenant {
+				t.Errorf("tenant = %q, want %q", tenant, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRevokedKey(t *testing.T) {
+	db, key, stored := tenantDB(t, 0)
+	// no cache, so the revocation applies to the next request
+	authenticator := NewAuthenticator(db, AuthConfig{Required: true})
+	handler := authenticator.Authenticate(ok)
+
+	if w, _ := serve(handler, key); w.Code != http.StatusOK {
+		t.Fatalf("status before revoking = %d, want 200", w.Code)
+	}
+	if err := db.RevokeAPIKey(context.Background(), stored.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	w, body := serve(handler, key)
+	if w.Code != http.StatusUnauthorized || body.Error != "INVALID_API_KEY" {
+		t.Errorf("revoked key: %d %s, want 401 INVALID_API_KEY", w.Code, body.Error)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	db, key, _ := tenantDB(t, 2)
+	authenticator := NewAuthenticator(db, AuthConfig{CacheTTL: time.Minute})
+	calls := 0
+	handler := authenticator.Authenticate(authenticator.Quota(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	tests := []struct {
+		status    int
+		remaining string
+	}{
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+		{http.StatusTooManyRequests, "0"},
+	}
+	for i, tt := range tests {
+		w, body := serve(handler, key)
+		if w.Code != tt.status {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, tt.status)
+		}
+		if got := w.Header().Get("X-Quota-Daily-Remaining"); got != tt.remaining {
+			t.Errorf("request %d: X-Quota-Daily-Remaining = %q, want %s", i+1, got, tt.remaining)
+		}
+		if tt.status == http.StatusTooManyRequests {
+			if body.Error != "QUOTA_EXCEEDED" {
+				t.Errorf("request %d: error = %q, want QUOTA_EXCEEDED", i+1, body.Error)
+			}
+			if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry < 1 || retry > 86400 {
+				t.Errorf("request %d: Retry-After = %q, want until the next UTC day", i+1, w.Header().Get("Retry-After"))
+			}
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+
+	// rejected requests aren't counted
+	usage, err := db.Usage(context.Background(), "acme", time.Now())
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage.DayCount != 2 {
+		t.Errorf("stored day count = %d, want 2", usage.DayCount)
+	}
+
+	// requests without a key are let through uncounted
+	if w, _ := serve(handler, ""); w.Code != http.StatusOK {
+		t.Errorf("anonymous request: status = %d, want 200", w.Code)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests int
+		allowed  bool
+		stored   int
+	}{
+		{"within the quota", 3, true, 3},
+		{"whole batch over the quota", 6, false, 0},
+		{"nothing to charge", 0, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, key, _ := tenantDB(t, 5)
+			authenticator := NewAuthenticator(db, AuthConfig{CacheTTL: time.Minute})
+			var allowed bool
+			handler := authenticator.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+				allowed = authenticator.Charge(w, r, tt.requests)
+			})
+			serve(handler, key)
+
+			if allowed != tt.allowed {
+				t.Errorf("Charge(%d) = %v, want %v", tt.requests, allowed, tt.allowed)
+			}
+			usage, err := db.Usage(context.Background(), "acme", time.Now())
+			if err != nil {
+				t.Fatalf("Usage: %v", err)
+			}
+			if usage.DayCount != tt.stored {
+				t.Errorf("stored day count = %d, want %d", usage.DayCount, tt.stored)
+			}
+		})
+	}
+}
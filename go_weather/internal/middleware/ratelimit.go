@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goweather/internal/logger"
+	"goweather/internal/metrics"
+)
+
+// RateLimitConfig configures the per-client token buckets.
+type RateLimitConfig struct {
+	Rate       float64       // tokens added per second
+	Burst      int           // bucket size, and the X-RateLimit-Limit value
+	IdleTTL    time.Duration // buckets unused this long are dropped
+	TrustProxy bool          // key by the first X-Forwarded-For address
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter keeps one token bucket per tenant, or per client IP for
+// callers without an API key. Idle buckets are swept on the way, so memory
+// follows the number of recently active clients.
+type RateLimiter struct {
+	config RateLimitConfig
+	logger *logger.Logger
+
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = 10 * time.Minute
+	}
+	return &RateLimiter{
+		config:    cfg,
+		logger:    logger.Get(),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// take spends one token for key. It returns whether the request may pass,
+// the whole tokens left, and how long until the next token (when denied)
+// or until the bucket is full again (when allowed).
+func (l *RateLimiter) take(key string, now time.Time) (bool, int, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.config.IdleTTL {
+		l.sweepLocked(now)
+	}
+
+	burst := float64(l.config.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.config.Rate)
+	}
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, 0, l.refillTime(1 - b.tokens)
+	}
+	b.tokens--
+	return true, int(b.tokens), l.refillTime(burst - b.tokens)
+}
+
+func (l *RateLimiter) refillTime(tokens float64) time.Duration {
+	if l.config.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.config.Rate * float64(time.Second))
+}
+
+func (l *RateLimiter) sweepLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.config.IdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Limit wraps next with the rate limit. Every response carries
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds
+// until the bucket is full); rejected requests get 429 with Retry-After.
+func (l *RateLimiter) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, keyType := l.clientKey(r)
+		allowed, remaining, wait := l.take(key, time.Now())
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.config.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(wait)))
+
+		if !allowed {
+			metrics.RateLimited.WithLabelValues(keyType).Inc()
+			l.logger.Ctx(r.Context()).Warn().
+				Str("component", "ratelimit").
+				Str("action", "rejected").
+				Str("key_type", keyType).
+				Str("path", r.URL.Path).
+				Dur("retry_after", wait).
+				Msg("Rate limit exceeded")
+
+			w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(wait), 1)))
+			writeError(w, http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests, retry after the Retry-After delay")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientKey identifies the caller: the tenant a valid API key resolved to,
+// else the IP. Keys sent by the caller are never used as such, so made-up
+// keys can't each get a fresh bucket; run Authenticate first.
+func (l *RateLimiter) clientKey(r *http.Request) (string, string) {
+	if tenant := authenticatedTenant(r.Context()); tenant != nil {
+		return "tenant:" + tenant.ID, "tenant"
+	}
+	return "ip:" + ClientIP(r, l.config.TrustProxy), "ip"
+}
+
+// APIKey returns the key sent in X-API-Key or as "Authorization: Bearer".
+func APIKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// ClientIP is the remote address, or the first X-Forwarded-For hop when
+// the service runs behind a trusted proxy.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// errorResponse matches the error body of the handlers package
+type errorResponse struct {
+	Error   string `json:"error"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(errorResponse{Error: errorCode, Code: statusCode, Message: message})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"goweather/pkg/types"
+)
+
+var t0 = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+func ok(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+// request comes from ip, as the tenant Authenticate resolved when tenant is set.
+func request(ip string, tenant *types.Tenant) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/weather?q=istanbul", nil)
+	r.RemoteAddr = ip + ":40000"
+	if tenant != nil {
+		r = r.WithContext(context.WithValue(WithTenant(r.Context(), tenant.ID), tenantRecordKey{}, tenant))
+	}
+	return r
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{Rate: 2, Burst: 3})
+	tests := []struct {
+		at        time.Duration
+		allowed   bool
+		remaining int
+		wait      time.Duration
+	}{
+		{0, true, 2, 500 * time.Millisecond}, // a new bucket starts full
+		{0, true, 1, time.Second},
+		{0, true, 0, 1500 * time.Millisecond},
+		{0, false, 0, 500 * time.Millisecond}, // burst spent
+		{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0, 1500 * time.Millisecond}, // one token refilled
+		{10 * time.Second, true, 2, 500 * time.Millisecond},        // refill stops at Burst
+	}
+	for i, tt := range tests {
+		allowed, remaining, wait := limiter.take("ip:192.0.2.1", t0.Add(tt.at))
+		if allowed != tt.allowed || remaining != tt.remaining || wait != tt.wait {
+			t.Errorf("take %d at +%v = (%v, %d, %v), want (%v, %d, %v)",
+				i+1, tt.at, allowed, remaining, wait, tt.allowed, tt.remaining, tt.wait)
+		}
+	}
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{Rate: 0.5, Burst: 2})
+	handler := limiter.Limit(ok)
+
+	tests := []struct {
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{http.StatusOK, "1", ""},
+		{http.StatusOK, "0", ""},
+		{http.StatusTooManyRequests, "0", "2"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, request("192.0.2.1", nil))
+
+		if w.Code != tt.status {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, tt.status)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i+1, got, tt.remaining)
+		}
+		if reset, err := strconv.Atoi(w.Header().Get("X-RateLimit-Reset")); err != nil || reset < 1 || reset > 4 {
+			t.Errorf("request %d: X-RateLimit-Reset = %q, want 1-4 seconds", i+1, w.Header().Get("X-RateLimit-Reset"))
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("request %d: Retry-After = %q, want %q", i+1, got, tt.retryAfter)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, request("192.0.2.1", nil))
+	var body errorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode 429 body: %v", err)
+	}
+	if body.Error != "RATE_LIMITED" || body.Code != http.StatusTooManyRequests {
+		t.Errorf("429 body = %+v, want RATE_LIMITED", body)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	acme := &types.Tenant{ID: "acme"}
+	globex := &types.Tenant{ID: "globex"}
+	tests := []struct {
+		name   string
+		first  *http.Request
+		second *http.Request
+		shared bool
+	}{
+		{"same IP without a key", request("192.0.2.1", nil), request("192.0.2.1", nil), true},
+		{"different IPs", request("192.0.2.1", nil), request("192.0.2.2", nil), false},
+		{"tenant across IPs", request("192.0.2.1", acme), request("192.0.2.2", acme), true},
+		{"tenants behind one IP", request("192.0.2.1", acme), request("192.0.2.1", globex), false},
+		{"tenant and anonymous on one IP", request("192.0.2.1", acme), request("192.0.2.1", nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRateLimiter(RateLimitConfig{Rate: 0.001, Burst: 1}).Limit(ok)
+			handler(httptest.NewRecorder(), tt.first)
+			w := httptest.NewRecorder()
+			handler(w, tt.second)
+			if limited := w.Code == http.StatusTooManyRequests; limited != tt.shared {
+				t.Errorf("second request limited = %v, want %v", limited, tt.shared)
+			}
+		})
+	}
+}
+
+func TestRateLimiterClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		forwarded  string
+		tenant     *types.Tenant
+		key        string
+		keyType    string
+	}{
+		{"remote address", false, "", nil, "ip:192.0.2.1", "ip"},
+		{"untrusted X-Forwarded-For", false, "198.51.100.7", nil, "ip:192.0.2.1", "ip"},
+		{"trusted X-Forwarded-For", true, "198.51.100.7, 10.0.0.1", nil, "ip:198.51.100.7", "ip"},
+		{"tenant", true, "198.51.100.7", &types.Tenant{ID: "acme"}, "tenant:acme", "tenant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := request("192.0.2.1", tt.tenant)
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			// an API key alone doesn't pick the bucket, only a resolved tenant
+			r.Header.Set("X-API-Key", "made-up")
+			limiter := NewRateLimiter(RateLimitConfig{Rate: 1, TrustProxy: tt.trustProxy})
+			if key, keyType := limiter.clientKey(r); key != tt.key || keyType != tt.keyType {
+				t.Errorf("clientKey = (%s, %s), want (%s, %s)", key, keyType, tt.key, tt.keyType)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{Rate: 1, Burst: 1, IdleTTL: time.Minute})
+	limiter.lastSweep = t0
+
+	limiter.take("ip:192.0.2.1", t0)
+	limiter.take("ip:192.0.2.2", t0.Add(40*time.Second))
+	limiter.take("ip:192.0.2.3", t0.Add(59*time.Second))
+	if len(limiter.buckets) != 3 {
+		t.Fatalf("buckets = %d before IdleTTL, want 3", len(limiter.buckets))
+	}
+
+	// the first take after IdleTTL sweeps buckets unused for that long
+	limiter.take("ip:192.0.2.3", t0.Add(time.Minute))
+	if _, ok := limiter.buckets["ip:192.0.2.1"]; ok {
+		t.Error("bucket idle for IdleTTL was kept")
+	}
+	if len(limiter.buckets) != 2 {
+		t.Errorf("buckets = %d after the sweep, want 2", len(limiter.buckets))
+	}
+
+	// a swept client starts over with a full bucket
+	if allowed, remaining, _ := limiter.take("ip:192.0.2.1", t0.Add(time.Minute)); !allowed || remaining != 0 {
+		t.Errorf("take after the sweep = (%v, %d), want a fresh bucket", allowed, remaining)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"goweather/internal/metrics"
+	"goweather/pkg/types"
+)
+
+// ErrGroupEvicted is returned to callers still waiting in an evicted group.
+var ErrGroupEvicted = errors.New("aggregation group evicted")
+
+// GroupInfo is the admin view of one aggregation group.
+type GroupInfo struct {
+	Key         string     `json:"key"`
+	Location    string     `json:"location"`
+	Days        int        `json:"days,omitempty"`
+	Strategy    string     `json:"strategy"`
+	MaxRequests int        `json:"max_requests"`
+	WaitTime    string     `json:"wait_time"`
+	Waiting     int        `json:"waiting"`
+	Processing  bool       `json:"processing"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Age         string     `json:"age"`
+	OldestWait  string     `json:"oldest_wait,omitempty"`
+	NextFlushAt *time.Time `json:"next_flush_at,omitempty"`
+}
+
+// AggregationSettings are the runtime aggregation settings.
+type AggregationSettings struct {
+	Strategy    string                  `json:"strategy"`
+	MaxRequests int                     `json:"max_requests"`
+	WaitTime    string                  `json:"wait_time"`
+	Overrides   map[string]OverrideInfo `json:"overrides"`
+}
+
+// OverrideInfo is one location's override; empty fields use the global value.
+type OverrideInfo struct {
+	Strategy    string `json:"strategy,omitempty"`
+	MaxRequests int    `json:"max_requests,omitempty"`
+	WaitTime    string `json:"wait_time,omitempty"`
+}
+
+// AggregationUpdate changes the wait time and/or max requests, globally or,
+// with Location set, for one location only. Zero values are left unchanged.
+type AggregationUpdate struct {
+	Location    string
+	MaxRequests int
+	WaitTime    time.Duration
+}
+
+func (s *WeatherService) groups() []*AggregationGroup {
+	s.aggregationMutex.RLock()
+	defer s.aggregationMutex.RUnlock()
+
+	groups := make([]*AggregationGroup, 0, len(s.aggregationMap))
+	for _, group := range s.aggregationMap {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// groupsFor returns the /weather and /forecast groups of a location query
+func (s *WeatherService) groupsFor(query string) []*AggregationGroup {
+	key := s.normalizer.Key(query)
+	var matched []*AggregationGroup
+	for _, group := range s.groups() {
+		if group.Location == key {
+			matched = append(matched, group)
+		}
+	}
+	return matched
+}
+
+// ListGroups reports every active aggregation group, sorted by key.
+func (s *WeatherService) ListGroups() []GroupInfo {
+	now := time.Now()
+	groups := s.groups()
+	infos := make([]GroupInfo, 0, len(groups))
+	for _, group := range groups {
+		group.Mutex.Lock()
+		settings := group.Strategy.Settings()
+		info := GroupInfo{
+			Key:         group.Key,
+			Location:    group.Location,
+			Days:        group.Days,
+			Strategy:    group.Strategy.Name(),
+			MaxRequests: settings.MaxRequests,
+			WaitTime:    settings.WaitTime.String(),
+			Waiting:     len(group.Requests),
+			Processing:  group.IsProcessing,
+			CreatedAt:   group.CreatedAt,
+			Age:         now.Sub(group.CreatedAt).Round(time.Millisecond).String(),
+		}
+		if len(group.Requests) > 0 {
+			info.OldestWait = now.Sub(group.Requests[0].JoinedAt).Round(time.Millisecond).String()
+		}
+		if group.Timer != nil {
+			deadline := group.Deadline
+			info.NextFlushAt = &deadline
+		}
+		group.Mutex.Unlock()
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+	return infos
+}
+
+// FlushLocation fires the pending batches of a location now and returns
+// how many groups were flushed. Groups already processing keep their
+// pending requests for the next batch.
+func (s *WeatherService) FlushLocation(query string) int {
+	flushed := 0
+	for _, group := range s.groupsFor(query) {
+		group.Mutex.Lock()
+		batch, ok := s.triggerLocked(group)
+		group.Mutex.Unlock()
+		if ok {
+			flushed++
+			go s.processAggregationGroupWithBatch(group, batch, TriggerManual)
+		}
+	}
+
+	s.logger.Info().
+		Str("component", "admin").
+		Str("action", "flush").
+		Str("location", query).
+		Int("flushed", flushed).
+		Msg("Aggregation groups flushed")
+	return flushed
+}
+
+// EvictLocation drops the groups of a location; waiting callers get
+// ErrGroupEvicted. Returns the number of groups and of dropped waiters.
+func (s *WeatherService) EvictLocation(query string) (int, int) {
+	groups := s.groupsFor(query)
+	dropped := 0
+	for _, group := range groups {
+		dropped += s.cleanupAggregationGroup(group)
+	}
+	metrics.GroupEvictions.WithLabelValues("admin").Add(float64(len(groups)))
+
+	s.logger.Info().
+		Str("component", "admin").
+		Str("action", "evict").
+		Str("location", query).
+		Int("groups", len(groups)).
+		Int("dropped_requests", dropped).
+		Msg("Aggregation groups evicted")
+	return len(groups), dropped
+}
+
+// AggregationSettings returns the global settings and per-location overrides
+func (s *WeatherService) AggregationSettings() AggregationSettings {
+	s.strategyMutex.RLock()
+	defer s.strategyMutex.RUnlock()
+
+	overrides := make(map[string]OverrideInfo, len(s.strategyOverrides))
+	for location, override := range s.strategyOverrides {
+		info := OverrideInfo{Strategy: override.Strategy, MaxRequests: override.MaxRequests}
+		if override.WaitTime > 0 {
+			info.WaitTime = override.WaitTime.String()
+		}
+		overrides[location] = info
+	}
+	return AggregationSettings{
+		Strategy:    s.strategyConfig.Name,
+		MaxRequests: s.strategyConfig.MaxRequests,
+		WaitTime:    s.strategyConfig.WaitTime.String(),
+		Overrides:   overrides,
+	}
+}
+
+// UpdateAggregation applies new wait/max settings and rebuilds the strategy
+// of every affected group, rescheduling or firing pending batches to match.
+func (s *WeatherService) UpdateAggregation(update AggregationUpdate) error {
+	if update.MaxRequests < 0 || update.WaitTime < 0 {
+		return fmt.Errorf("max_requests and wait_time must not be negative")
+	}
+
+	key := ""
+	if update.Location != "" {
+		key = s.normalizer.Key(update.Location)
+		if key == "" {
+			return fmt.Errorf("location is empty")
+		}
+	}
+
+	s.strategyMutex.Lock()
+	if key == "" {
+		if update.MaxRequests > 0 {
+			s.strategyConfig.MaxRequests = update.MaxRequests
+		}
+		if update.WaitTime > 0 {
+			s.strategyConfig.WaitTime = update.WaitTime
+		}
+	} else {
+		override := s.strategyOverrides[key]
+		if update.MaxRequests > 0 {
+			override.MaxRequests = update.MaxRequests
+		}
+		if update.WaitTime > 0 {
+			override.WaitTime = update.WaitTime
+		}
+		s.strategyOverrides[key] = override
+	}
+	s.strategyMutex.Unlock()
+
+	for _, group := range s.groups() {
+		if key != "" && group.Location != key {
+			continue
+		}
+		strategy := s.strategyFor(group.Location)
+
+		group.Mutex.Lock()
+		group.Strategy = strategy
+		var batch []types.AggregationRequest
+		fire := false
+		if !group.IsProcessing && strategy.ShouldFire(len(group.Requests)) {
+			batch, fire = s.triggerLocked(group)
+		} else {
+			s.startTimerLocked(group)
+		}
+		group.Mutex.Unlock()
+		if fire {
+			go s.processAggregationGroupWithBatch(group, batch, TriggerManual)
+		}
+	}
+
+	s.logger.Info().
+		Str("component", "admin").
+		Str("action", "update_aggregation").
+		Str("location", key).
+		Int("max_requests", update.MaxRequests).
+		Dur("wait_time", update.WaitTime).
+		Msg("Aggregation settings updated")
+	return nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"goweather/pkg/types"
+)
+
+// BatchMaxLocations is the most locations GetWeatherBatch callers may send
+func (s *WeatherService) BatchMaxLocations() int {
+	return s.batchMaxLocations
+}
+
+// GetWeatherBatch joins every location to its own aggregation group at once
+// and waits for all of them. Results and errors are keyed by the query as
+// given; duplicate queries are fetched once.
+func (s *WeatherService) GetWeatherBatch(ctx context.Context, queries []string) (map[string]*types.WeatherResponse, map[string]error) {
+	results := make(map[string]*types.WeatherResponse, len(queries))
+	errs := make(map[string]error)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(queries))
+	for _, query := range queries {
+		if seen[query] {
+			continue
+		}
+		seen[query] = true
+
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			response, err := s.GetWeather(ctx, query)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs[query] = err
+				return
+			}
+			results[query] = response
+		}(query)
+	}
+	wg.Wait()
+
+	return results, errs
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"math"
+
+	"goweather/pkg/types"
+)
+
+// mergeConditions averages what the contributing providers reported. Each
+// optional field is averaged over the providers that actually sent it;
+// condition text and code come from the first provider that has them.
+func mergeConditions(reported []*types.Conditions) types.Conditions {
+	var merged types.Conditions
+	if len(reported) == 0 {
+		return merged
+	}
+
+	var totalTemp float64
+	for _, c := range reported {
+		totalTemp += c.Temperature
+	}
+	merged.Temperature = totalTemp / float64(len(reported))
+
+	merged.FeelsLike = averageOf(reported, func(c *types.Conditions) *float64 { return c.FeelsLike })
+	merged.Humidity = averageOf(reported, func(c *types.Conditions) *float64 { return c.Humidity })
+	merged.WindSpeed = averageOf(reported, func(c *types.Conditions) *float64 { return c.WindSpeed })
+	merged.Pressure = averageOf(reported, func(c *types.Conditions) *float64 { return c.Pressure })
+	merged.WindDirection = averageDirection(reported)
+
+	for _, c := range reported {
+		if merged.ConditionText == "" && c.ConditionText != "" {
+			merged.ConditionText = c.ConditionText
+		}
+		if merged.ConditionCode == nil && c.ConditionCode != nil {
+			code := *c.ConditionCode
+			merged.ConditionCode = &code
+		}
+		if c.ObservedAt != nil && (merged.ObservedAt == nil || c.ObservedAt.After(*merged.ObservedAt)) {
+			observedAt := *c.ObservedAt
+			merged.ObservedAt = &observedAt
+		}
+	}
+	return merged
+}
+
+func averageOf(reported []*types.Conditions, field func(*types.Conditions) *float64) *float64 {
+	var total float64
+	var count int
+	for _, c := range reported {
+		if value := field(c); value != nil {
+			total += *value
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	average := total / float64(count)
+	return &average
+}
+
+// averageDirection uses the circular mean so 350° and 10° average to 0°, not 180°.
+func averageDirection(reported []*types.Conditions) *float64 {
+	var x, y float64
+	var count int
+	for _, c := range reported {
+		if c.WindDirection == nil {
+			continue
+		}
+		radians := *c.WindDirection * math.Pi / 180
+		x += math.Cos(radians)
+		y += math.Sin(radians)
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	degrees := math.Mod(math.Round(math.Atan2(y, x)*180/math.Pi)+360, 360)
+	return &degrees
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goweather/internal/clients"
+	"goweather/internal/metrics"
+	"goweather/internal/tracing"
+	"goweather/pkg/types"
+)
+
+// ErrInvalidDays is returned for a forecast length outside 1..FORECAST_MAX_DAYS.
+var ErrInvalidDays = errors.New("invalid forecast days")
+
+// groupKey is the aggregationMap key: /weather groups use the location,
+// forecast groups add the number of days so each length batches separately.
+func groupKey(location string, days int) string {
+	if days <= 0 {
+		return location
+	}
+	return fmt.Sprintf("%s|forecast:%d", location, days)
+}
+
+// ForecastMaxDays is the longest forecast GetForecast accepts
+func (s *WeatherService) ForecastMaxDays() int {
+	return s.forecastMaxDays
+}
+
+// GetForecast joins the forecast group for query and days and waits for the
+// daily temperatures averaged across every provider that serves forecasts.
+func (s *WeatherService) GetForecast(ctx context.Context, query string, days int) (response *types.ForecastResponse, err error) {
+	if days < 1 || days > s.forecastMaxDays {
+		return nil, fmt.Errorf("%w: %d, expected 1-%d", ErrInvalidDays, days, s.forecastMaxDays)
+	}
+	key := s.normalizer.Key(query)
+	if key == "" {
+		return nil, fmt.Errorf("location is empty")
+	}
+
+	if cached, ok := s.cachedForecast(groupKey(key, days)); ok {
+		cached.Location = query
+		trace.SpanFromContext(ctx).AddEvent("cache_hit")
+		return cached, nil
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "aggregation.wait", trace.WithAttributes(
+		attribute.String("location", key),
+		attribute.Int("forecast.days", days),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	request := types.AggregationRequest{
+		Context:  ctx,
+		JoinedAt: time.Now(),
+		Location: key,
+		Forecast: make(chan types.ForecastResponse, 1),
+		Error:    make(chan error, 1),
+	}
+	group, err := s.getOrCreateAggregationGroup(key, days)
+	for err == nil && !s.joinGroup(group, request) {
+		group, err = s.getOrCreateAggregationGroup(key, days)
+	}
+	if errors.Is(err, errOverflowDirect) {
+		forecast, _, err := s.fetchForecast(ctx, key, days)
+		if err != nil {
+			return nil, err
+		}
+		return &types.ForecastResponse{Location: query, Days: days, Forecast: forecast}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-request.Forecast:
+		response.Location = query
+		return &response, nil
+	case err := <-request.Error:
+		return nil, err
+	case <-ctx.Done():
+		s.leaveGroup(group, request)
+		return nil, ctx.Err()
+	}
+}
+
+// completeForecastBatch fetches the forecast and answers the whole batch
+func (s *WeatherService) completeForecastBatch(ctx context.Context, group *AggregationGroup, batch []types.AggregationRequest) error {
+	forecast, contributors, err := s.fetchForecast(ctx, group.Location, group.Days)
+	if err != nil {
+		return err
+	}
+
+	response := types.ForecastResponse{
+		Location: group.Location,
+		Days:     group.Days,
+		Forecast: forecast,
+	}
+	if s.forecastCache != nil {
+		s.forecastCache.Set(group.Key, response)
+	}
+	for _, req := range batch {
+		req.Forecast <- response
+	}
+
+	s.logger.Ctx(ctx).Info().
+		Str("component", "aggregation").
+		Str("action", "forecast_batch_completed").
+		Str("location", group.Location).
+		Int("days", group.Days).
+		Strs("providers", contributors).
+		Int("request_count", len(batch)).
+		Msg("Forecast batch completed")
+	return nil
+}
+
+// fetchForecast asks every forecast provider concurrently and averages
+// min/max/avg per date over the providers that returned that date.
+func (s *WeatherService) fetchForecast(ctx context.Context, location string, days int) ([]types.ForecastDay, []string, error) {
+	providers := s.providers.ForecastProviders()
+	if len(providers) == 0 {
+		return nil, nil, fmt.Errorf("no registered weather provider serves forecasts")
+	}
+
+	forecasts := make([][]types.ForecastDay, len(providers))
+	errs := make([]error, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider clients.ForecastProvider) {
+			defer wg.Done()
+			providerCtx, span := tracing.Tracer().Start(ctx, "provider.forecast", trace.WithAttributes(
+				attribute.String("provider", provider.Name()),
+				attribute.String("location", location),
+				attribute.Int("forecast.days", days),
+			))
+			startTime := time.Now()
+			forecasts[i], errs[i] = provider.GetForecast(providerCtx, location, days)
+			metrics.ProviderLatency.WithLabelValues(provider.Name()).Observe(time.Since(startTime).Seconds())
+			tracing.End(span, errs[i])
+			if errs[i] != nil && !errors.Is(errs[i], context.Canceled) {
+				metrics.ProviderErrors.WithLabelValues(provider.Name()).Inc()
+			}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var contributors []string
+	var contributing [][]types.ForecastDay
+	for i, provider := range providers {
+		if errs[i] != nil {
+			if ctx.Err() == nil {
+				s.logger.Ctx(ctx).AggregationProviderFailed(location, provider.Name(), errs[i])
+			}
+			continue
+		}
+		contributors = append(contributors, provider.Name())
+		for d := range forecasts[i] {
+			forecasts[i][d].Contributors = []string{provider.Name()}
+		}
+		contributing = append(contributing, forecasts[i])
+	}
+	if len(contributors) == 0 {
+		return nil, nil, fmt.Errorf("no forecast provider succeeded: %v", errors.Join(errs...))
+	}
+
+	return mergeForecasts(contributing, days), contributors, nil
+}
+
+func mergeForecasts(forecasts [][]types.ForecastDay, days int) []types.ForecastDay {
+	type totals struct {
+		min, max, avg float64
+		providers     []string
+	}
+	byDate := make(map[string]*totals)
+	for _, forecast := range forecasts {
+		for _, day := range forecast {
+			t, ok := byDate[day.Date]
+			if !ok {
+				t = &totals{}
+				byDate[day.Date] = t
+			}
+			t.min += day.MinTemp
+			t.max += day.MaxTemp
+			t.avg += day.AvgTemp
+			t.providers = append(t.providers, day.Contributors...)
+		}
+	}
+
+	merged := make([]types.ForecastDay, 0, len(byDate))
+	for date, t := range byDate {
+		count := float64(len(t.providers))
+		merged = append(merged, types.ForecastDay{
+			Date:         date,
+			MinTemp:      t.min / count,
+			MaxTemp:      t.max / count,
+			AvgTemp:      t.avg / count,
+			Contributors: t.providers,
+		})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Date < merged[j].Date
+	})
+	if len(merged) > days {
+		merged = merged[:days]
+	}
+	return merged
+}
+
+func (s *WeatherService) cachedForecast(key string) (*types.ForecastResponse, bool) {
+	if s.forecastCache == nil {
+		return nil, false
+	}
+	response, ok := s.forecastCache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	s.logger.CacheHit(key)
+	return &response, true
+}
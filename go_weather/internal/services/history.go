@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"goweather/internal/database"
+	"goweather/pkg/types"
+)
+
+// Page sizes for History
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+)
+
+// ErrInvalidHistoryQuery wraps every rejected /history parameter
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+// History returns one page of logged upstream fetches. The location filter
+// is normalized like /weather queries, so "istanbul " finds "Istanbul" rows.
+func (s *WeatherService) History(ctx context.Context, query database.HistoryQuery) (*types.HistoryPage, error) {
+	if query.Sort == "" {
+		query.Sort = database.HistorySortCreatedAt
+	}
+	if query.Sort != database.HistorySortCreatedAt && query.Sort != database.HistorySortRequestCount {
+		return nil, fmt.Errorf("%w: sort must be %s or %s", ErrInvalidHistoryQuery,
+			database.HistorySortCreatedAt, database.HistorySortRequestCount)
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultHistoryLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be 1-%d", ErrInvalidHistoryQuery, MaxHistoryLimit)
+	}
+	filter, err := s.historyFilter(query.HistoryFilter)
+	if err != nil {
+		return nil, err
+	}
+	query.HistoryFilter = filter
+
+	page, err := s.db.QueryHistory(ctx, query)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHistoryQuery, err)
+	}
+	return page, err
+}
+
+// HistoryStats aggregates the logged fetches matching filter.
+func (s *WeatherService) HistoryStats(ctx context.Context, filter database.HistoryFilter) (*types.HistoryStats, error) {
+	filter, err := s.historyFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.HistoryStats(ctx, filter)
+}
+
+func (s *WeatherService) historyFilter(filter database.HistoryFilter) (database.HistoryFilter, error) {
+	if strings.TrimSpace(filter.Location) != "" {
+		filter.Location = s.normalizer.Key(filter.Location)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("%w: from must be before to", ErrInvalidHistoryQuery)
+	}
+	return filter, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"goweather/internal/metrics"
+)
+
+// What getOrCreateAggregationGroup does for a new location once MAX_GROUPS
+// groups exist (GROUP_OVERFLOW).
+const (
+	OverflowReject = "reject" // fail the request with ErrTooManyGroups
+	OverflowDirect = "direct" // fetch for this caller alone, without a group
+	OverflowEvict  = "evict"  // evict the longest idle group; reject if none is idle
+)
+
+// ErrTooManyGroups is returned when the group cap is reached and no room
+// could be made for a new location.
+var ErrTooManyGroups = errors.New("too many active aggregation groups")
+
+// errOverflowDirect tells the caller to skip aggregation for this request
+var errOverflowDirect = errors.New("aggregation group cap reached, fetching directly")
+
+// idleLocked reports whether group has nothing pending or in flight and
+// hasn't been used for at least ttl. Caller holds group.Mutex.
+func idleLocked(group *AggregationGroup, now time.Time, ttl time.Duration) bool {
+	return !group.IsProcessing &&
+		len(group.Requests) == 0 &&
+		group.Timer == nil &&
+		now.Sub(group.LastUsed) >= ttl
+}
+
+// runJanitor removes idle groups every interval until Shutdown.
+func (s *WeatherService) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := s.sweepIdleGroups(); removed > 0 {
+				s.logger.Debug().
+					Str("component", "aggregation").
+					Str("action", "janitor_sweep").
+					Int("removed", removed).
+					Int("remaining", s.groupCount()).
+					Msg("Idle aggregation groups removed")
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// sweepIdleGroups deletes every group idle for longer than GROUP_IDLE_TTL.
+// Groups are checked and marked evicted under both locks, so a request that
+// looked a group up just before is sent back to create a fresh one.
+func (s *WeatherService) sweepIdleGroups() int {
+	now := time.Now()
+
+	s.aggregationMutex.Lock()
+	defer s.aggregationMutex.Unlock()
+
+	removed := 0
+	for key, group := range s.aggregationMap {
+		group.Mutex.Lock()
+		if idleLocked(group, now, s.groupIdleTTL) {
+			group.Evicted = true
+			delete(s.aggregationMap, key)
+			removed++
+		}
+		group.Mutex.Unlock()
+	}
+
+	metrics.GroupEvictions.WithLabelValues("idle").Add(float64(removed))
+	metrics.Groups.Set(float64(len(s.aggregationMap)))
+	return removed
+}
+
+// evictIdlestLocked removes the group unused for the longest time among
+// those with nothing pending or in flight. Caller holds aggregationMutex.
+func (s *WeatherService) evictIdlestLocked() bool {
+	now := time.Now()
+	var idlest *AggregationGroup
+	for _, group := range s.aggregationMap {
+		group.Mutex.Lock()
+		if idleLocked(group, now, 0) && (idlest == nil || group.LastUsed.Before(idlest.LastUsed)) {
+			idlest = group
+		}
+		group.Mutex.Unlock()
+	}
+	if idlest == nil {
+		return false
+	}
+
+	// a caller holding the group from an earlier lookup may have joined since
+	idlest.Mutex.Lock()
+	defer idlest.Mutex.Unlock()
+	if !idleLocked(idlest, now, 0) {
+		return false
+	}
+	idlest.Evicted = true
+	delete(s.aggregationMap, idlest.Key)
+	metrics.GroupEvictions.WithLabelValues("overflow").Inc()
+	return true
+}
+
+// admitGroupLocked applies GROUP_OVERFLOW when a new group would exceed
+// MAX_GROUPS. Caller holds aggregationMutex.
+func (s *WeatherService) admitGroupLocked(key string) error {
+	if s.maxGroups <= 0 || len(s.aggregationMap) < s.maxGroups {
+		return nil
+	}
+
+	behavior := s.groupOverflow
+	metrics.GroupOverflows.WithLabelValues(behavior).Inc()
+	s.logger.Warn().
+		Str("component", "aggregation").
+		Str("action", "group_overflow").
+		Str("location", key).
+		Int("max_groups", s.maxGroups).
+		Str("behavior", behavior).
+		Msg("Aggregation group cap reached")
+
+	switch behavior {
+	case OverflowDirect:
+		return errOverflowDirect
+	case OverflowEvict:
+		if s.evictIdlestLocked() {
+			return nil
+		}
+		return ErrTooManyGroups
+	default:
+		return ErrTooManyGroups
+	}
+}
+
+func (s *WeatherService) groupCount() int {
+	s.aggregationMutex.RLock()
+	defer s.aggregationMutex.RUnlock()
+	return len(s.aggregationMap)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"goweather/internal/config"
+)
+
+// Aggregation strategy names accepted by AGGREGATION_STRATEGY and overrides
+const (
+	StrategyFixed    = "fixed"
+	StrategyDebounce = "debounce"
+	StrategyAdaptive = "adaptive"
+)
+
+// Strategy decides when an aggregation group sends its pending requests
+// upstream. Every group owns its own instance and calls it with the group
+// mutex held, so implementations need no locking of their own.
+type Strategy interface {
+	// Name is the strategy name used in config and logs.
+	Name() string
+	// ShouldFire reports whether pending requests go out without waiting.
+	ShouldFire(pending int) bool
+	// Deadline is when a batch whose first and latest requests joined at
+	// the given times should fire.
+	Deadline(firstJoined, lastJoined time.Time) time.Time
+	// Observe is told how long each completed batch spent upstream.
+	Observe(latency time.Duration)
+	// Settings returns the configuration the strategy was built with.
+	Settings() StrategyConfig
+}
+
+// StrategyConfig holds the settings shared by every strategy; each one
+// ignores what it doesn't use.
+type StrategyConfig struct {
+	Name        string
+	MaxRequests int
+	WaitTime    time.Duration // fixed window, debounce cap, adaptive upper bound
+	QuietPeriod time.Duration // debounce: fire after this long without a new request
+	MinWait     time.Duration // adaptive: the window never shrinks below this
+}
+
+func (c StrategyConfig) Settings() StrategyConfig { return c }
+
+// NewStrategy builds the named strategy; unknown names fall back to fixed.
+func NewStrategy(cfg StrategyConfig) Strategy {
+	switch strings.ToLower(cfg.Name) {
+	case StrategyDebounce:
+		return &debounceStrategy{StrategyConfig: cfg}
+	case StrategyAdaptive:
+		return &adaptiveStrategy{StrategyConfig: cfg}
+	default:
+		return &fixedStrategy{StrategyConfig: cfg}
+	}
+}
+
+// fixedStrategy fires WaitTime after the first request or at MaxRequests,
+// whichever comes first.
+type fixedStrategy struct {
+	StrategyConfig
+}
+
+func (f *fixedStrategy) Name() string { return StrategyFixed }
+
+func (f *fixedStrategy) ShouldFire(pending int) bool { return pending >= f.MaxRequests }
+
+func (f *fixedStrategy) Deadline(firstJoined, _ time.Time) time.Time {
+	return firstJoined.Add(f.WaitTime)
+}
+
+func (f *fixedStrategy) Observe(time.Duration) {}
+
+// debounceStrategy fires once no request has joined for QuietPeriod, but
+// never later than WaitTime after the first one.
+type debounceStrategy struct {
+	StrategyConfig
+}
+
+func (d *debounceStrategy) Name() string { return StrategyDebounce }
+
+func (d *debounceStrategy) ShouldFire(pending int) bool { return pending >= d.MaxRequests }
+
+func (d *debounceStrategy) Deadline(firstJoined, lastJoined time.Time) time.Time {
+	deadline := lastJoined.Add(d.QuietPeriod)
+	if limit := firstJoined.Add(d.WaitTime); deadline.After(limit) {
+		return limit
+	}
+	return deadline
+}
+
+func (d *debounceStrategy) Observe(time.Duration) {}
+
+// adaptiveStrategy keeps wait plus upstream latency near WaitTime: the
+// slower the providers have been lately, the shorter the window.
+type adaptiveStrategy struct {
+	StrategyConfig
+	latency time.Duration // exponentially weighted moving average
+}
+
+// adaptiveWeight is how much the newest batch moves the latency average
+const adaptiveWeight = 0.3
+
+func (a *adaptiveStrategy) Name() string { return StrategyAdaptive }
+
+func (a *adaptiveStrategy) ShouldFire(pending int) bool { return pending >= a.MaxRequests }
+
+func (a *adaptiveStrategy) Deadline(firstJoined, _ time.Time) time.Time {
+	return firstJoined.Add(max(a.WaitTime-a.latency, a.MinWait))
+}
+
+func (a *adaptiveStrategy) Observe(latency time.Duration) {
+	if a.latency == 0 {
+		a.latency = latency
+		return
+	}
+	a.latency = time.Duration(adaptiveWeight*float64(latency) + (1-adaptiveWeight)*float64(a.latency))
+}
+
+// strategyFor builds the strategy for a location: the global settings with
+// any AGGREGATION_OVERRIDES entry for that location applied on top.
+func (s *WeatherService) strategyFor(location string) Strategy {
+	s.strategyMutex.RLock()
+	defer s.strategyMutex.RUnlock()
+
+	cfg := s.strategyConfig
+	if override, ok := s.strategyOverrides[location]; ok {
+		if override.Strategy != "" {
+			cfg.Name = override.Strategy
+		}
+		if override.MaxRequests > 0 {
+			cfg.MaxRequests = override.MaxRequests
+		}
+		if override.WaitTime > 0 {
+			cfg.WaitTime = override.WaitTime
+		}
+	}
+	return NewStrategy(cfg)
+}
+
+func strategyOverrides(cfg *config.Config, key func(string) string) map[string]config.AggregationOverride {
+	overrides := make(map[string]config.AggregationOverride, len(cfg.AggregationOverrides))
+	for location, override := range cfg.AggregationOverrides {
+		overrides[key(location)] = override
+	}
+	return overrides
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"goweather/internal/config"
+	"goweather/internal/location"
+)
+
+var t0 = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+func TestStrategyDeadline(t *testing.T) {
+	cfg := StrategyConfig{MaxRequests: 10, WaitTime: 5 * time.Second, QuietPeriod: time.Second, MinWait: 500 * time.Millisecond}
+	tests := []struct {
+		name       string
+		strategy   string
+		lastJoined time.Duration // after the first request
+		want       time.Duration // after the first request
+	}{
+		{"fixed ignores later joins", StrategyFixed, 3 * time.Second, 5 * time.Second},
+		{"debounce after the quiet period", StrategyDebounce, 0, time.Second},
+		{"debounce moves with each join", StrategyDebounce, 2 * time.Second, 3 * time.Second},
+		{"debounce capped at WaitTime", StrategyDebounce, 4500 * time.Millisecond, 5 * time.Second},
+		{"adaptive without observations", StrategyAdaptive, 0, 5 * time.Second},
+		{"unknown falls back to fixed", "sliding", 3 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Name = tt.strategy
+			strategy := NewStrategy(cfg)
+			if got := strategy.Deadline(t0, t0.Add(tt.lastJoined)); !got.Equal(t0.Add(tt.want)) {
+				t.Errorf("Deadline = +%v, want +%v", got.Sub(t0), tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategyNames(t *testing.T) {
+	for name, want := range map[string]string{
+		"fixed":    StrategyFixed,
+		"DEBOUNCE": StrategyDebounce,
+		"Adaptive": StrategyAdaptive,
+		"":         StrategyFixed,
+		"sliding":  StrategyFixed,
+	} {
+		if got := NewStrategy(StrategyConfig{Name: name}).Name(); got != want {
+			t.Errorf("NewStrategy(%q).Name() = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestStrategyShouldFire(t *testing.T) {
+	for _, name := range []string{StrategyFixed, StrategyDebounce, StrategyAdaptive} {
+		strategy := NewStrategy(StrategyConfig{Name: name, MaxRequests: 3})
+		for pending, want := range map[int]bool{1: false, 2: false, 3: true, 4: true} {
+			if got := strategy.ShouldFire(pending); got != want {
+				t.Errorf("%s: ShouldFire(%d) = %v, want %v", name, pending, got, want)
+			}
+		}
+	}
+}
+
+func TestAdaptiveStrategyObserve(t *testing.T) {
+	strategy := NewStrategy(StrategyConfig{Name: StrategyAdaptive, WaitTime: 5 * time.Second, MinWait: 500 * time.Millisecond})
+	tests := []struct {
+		latency time.Duration
+		want    time.Duration // window after the observation
+	}{
+		{time.Second, 4 * time.Second},                    // first observation is taken as is
+		{2 * time.Second, 3700 * time.Millisecond},        // 0.3*2s + 0.7*1s = 1.3s
+		{20 * time.Second, 500 * time.Millisecond},        // never below MinWait
+		{0, 500 * time.Millisecond},                       // 0.7*6.91s = 4.84s leaves less than MinWait
+		{100 * time.Millisecond, 1584 * time.Millisecond}, // 0.03s + 0.7*4.84s = 3.42s
+	}
+	for i, tt := range tests {
+		strategy.Observe(tt.latency)
+		got := strategy.Deadline(t0, t0).Sub(t0)
+		if diff := got - tt.want; diff < -time.Millisecond || diff > time.Millisecond {
+			t.Errorf("after observation %d (%v): window = %v, want %v", i+1, tt.latency, got, tt.want)
+		}
+	}
+}
+
+func TestStrategyForOverrides(t *testing.T) {
+	normalizer := location.NewNormalizer(location.DefaultAliases, 2)
+	cfg := &config.Config{AggregationOverrides: map[string]config.AggregationOverride{
+		"İstanbul": {Strategy: StrategyDebounce, WaitTime: 2 * time.Second},
+		"Ankara":   {MaxRequests: 50},
+	}}
+	s := &WeatherService{
+		strategyConfig:    StrategyConfig{Name: StrategyFixed, MaxRequests: 10, WaitTime: 5 * time.Second, QuietPeriod: time.Second},
+		strategyOverrides: strategyOverrides(cfg, normalizer.Key),
+	}
+
+	tests := []struct {
+		location string
+		want     StrategyConfig
+	}{
+		{"istanbul", StrategyConfig{Name: StrategyDebounce, MaxRequests: 10, WaitTime: 2 * time.Second, QuietPeriod: time.Second}},
+		{"ankara", StrategyConfig{Name: StrategyFixed, MaxRequests: 50, WaitTime: 5 * time.Second, QuietPeriod: time.Second}},
+		{"izmir", StrategyConfig{Name: StrategyFixed, MaxRequests: 10, WaitTime: 5 * time.Second, QuietPeriod: time.Second}},
+	}
+	for _, tt := range tests {
+		strategy := s.strategyFor(tt.location)
+		if got := strategy.Settings(); got != tt.want {
+			t.Errorf("strategyFor(%s) settings = %+v, want %+v", tt.location, got, tt.want)
+		}
+		if strategy.Name() != tt.want.Name {
+			t.Errorf("strategyFor(%s) = %s, want %s", tt.location, strategy.Name(), tt.want.Name)
+		}
+	}
+}
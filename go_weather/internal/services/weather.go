@@ -1,134 +1,367 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goweather/internal/cache"
 	"goweather/internal/clients"
 	"goweather/internal/config"
 	"goweather/internal/database"
+	"goweather/internal/location"
 	"goweather/internal/logger"
+	"goweather/internal/metrics"
+	"goweather/internal/middleware"
+	"goweather/internal/tracing"
 	"goweather/pkg/types"
 )
 
 type WeatherService struct {
-	weatherAPIClient  *clients.WeatherAPIClient
-	weatherStackClient *clients.WeatherStackClient
-	database          *database.Database
+	providers         *clients.Registry
+	db                database.Store
+	writer            *database.Writer
 	logger            *logger.Logger
+	normalizer        *location.Normalizer
 	
 	aggregationMap    map[string]*AggregationGroup
 	aggregationMutex  sync.RWMutex
 	
-	maxRequests       int
-	waitTime          time.Duration
+	// nil when CACHE_TTL is 0
+	cache             *cache.Cache[types.WeatherResponse]
+	forecastCache     *cache.Cache[types.ForecastResponse]
+	forecastMaxDays   int
+	batchMaxLocations int
+	
+	// per-group aggregation strategy: global settings plus per-location overrides,
+	// both changeable at runtime through the admin API
+	strategyMutex     sync.RWMutex
+	strategyConfig    StrategyConfig
+	strategyOverrides map[string]config.AggregationOverride
+	
+	quorum            int
+	
+	// locations with their own metrics label; everything else is "other"
+	trackedLocations  map[string]bool
+	
+	// shutdown: draining fires batches without waiting, batches tracks in-flight work
+	draining          atomic.Bool
+	batches           sync.WaitGroup
+	
+	// group lifecycle: idle groups are swept, MAX_GROUPS caps the map
+	groupIdleTTL      time.Duration
+	maxGroups         int
+	groupOverflow     string
+	stop              chan struct{}
+	stopOnce          sync.Once
 }
 
+// Batch triggers, recorded in metrics and in weather_queries.trigger_reason
+const (
+	TriggerTimer      = "timer"
+	TriggerMaxReached = "max_reached"
+	TriggerFlush      = "flush"
+	TriggerManual     = "manual" // admin API flush or settings change
+	TriggerDirect     = "direct" // fetched outside aggregation (GROUP_OVERFLOW=direct)
+)
+
 type AggregationGroup struct {
+	Key          string // aggregationMap key; Location, plus the days for forecasts
 	Location     string
+	Days         int    // 0 for /weather, the forecast length for /forecast
 	Requests     []types.AggregationRequest
 	Timer        *time.Timer
 	Mutex        sync.Mutex
-	MaxRequests  int
-	WaitTime     time.Duration
+	Strategy     Strategy
+	Deadline     time.Time // when Timer fires
+	CreatedAt    time.Time
+	LastUsed     time.Time // last join or batch completion; the janitor evicts by it
 	IsProcessing bool
+	Evicted      bool // removed from aggregationMap; joinGroup refuses new requests
 }
 
-func NewWeatherService(db *database.Database, cfg *config.Config) *WeatherService {
-	return &WeatherService{
-		weatherAPIClient:  clients.NewWeatherAPIClient(cfg.WeatherAPIKey, cfg.APITimeout),
-		weatherStackClient: clients.NewWeatherStackClient(cfg.WeatherStackKey, cfg.APITimeout),
-		database:          db,
+func NewWeatherService(db database.Store, providers *clients.Registry, cfg *config.Config) *WeatherService {
+	var responseCache *cache.Cache[types.WeatherResponse]
+	var forecastCache *cache.Cache[types.ForecastResponse]
+	if cfg.CacheTTL > 0 {
+		responseCache = cache.New[types.WeatherResponse](cfg.CacheTTL, cfg.CacheMaxEntries)
+		forecastCache = cache.New[types.ForecastResponse](cfg.CacheTTL, cfg.CacheMaxEntries)
+	}
+
+	writer := database.NewWriter(db, database.WriterConfig{
+		QueueSize:     cfg.DBQueueSize,
+		BatchSize:     cfg.DBBatchSize,
+		FlushInterval: cfg.DBFlushInterval,
+		DropPolicy:    cfg.DBDropPolicy,
+		BlockTimeout:  cfg.DBBlockTimeout,
+	})
+
+	normalizer := newNormalizer(cfg)
+	trackedLocations := make(map[string]bool, len(cfg.MetricsLocations))
+	for _, name := range cfg.MetricsLocations {
+		trackedLocations[normalizer.Key(name)] = true
+	}
+
+	s := &WeatherService{
+		providers:         providers,
+		db:                db,
+		writer:            writer,
 		logger:            logger.Get(),
+		normalizer:        normalizer,
 		aggregationMap:    make(map[string]*AggregationGroup),
-		maxRequests:       cfg.MaxRequests,
-		waitTime:          cfg.WaitTime,
+		cache:             responseCache,
+		forecastCache:     forecastCache,
+		forecastMaxDays:   cfg.ForecastMaxDays,
+		batchMaxLocations: cfg.BatchMaxLocations,
+		strategyConfig:    StrategyConfig{
+			Name:        cfg.AggregationStrategy,
+			MaxRequests: cfg.MaxRequests,
+			WaitTime:    cfg.WaitTime,
+			QuietPeriod: cfg.AggregationQuietPeriod,
+			MinWait:     cfg.AggregationMinWait,
+		},
+		strategyOverrides: strategyOverrides(cfg, normalizer.Key),
+		groupIdleTTL:      cfg.GroupIdleTTL,
+		maxGroups:         cfg.MaxGroups,
+		groupOverflow:     cfg.GroupOverflow,
+		stop:              make(chan struct{}),
+		quorum:            cfg.ProviderQuorum,
+		trackedLocations:  trackedLocations,
+	}
+	
+	if cfg.GroupIdleTTL > 0 && cfg.GroupJanitorInterval > 0 {
+		go s.runJanitor(cfg.GroupJanitorInterval)
+	}
+	return s
+}
+
+// FlushAll stops waiting on every aggregation group: pending batches are
+// fired now and requests arriving from here on are processed immediately.
+func (s *WeatherService) FlushAll() int {
+	s.draining.Store(true)
+
+	s.aggregationMutex.RLock()
+	groups := make([]*AggregationGroup, 0, len(s.aggregationMap))
+	for _, group := range s.aggregationMap {
+		groups = append(groups, group)
+	}
+	s.aggregationMutex.RUnlock()
+
+	flushed := 0
+	for _, group := range groups {
+		group.Mutex.Lock()
+		// a group that is still processing loses its timer here and
+		// restarts it with no delay once the running batch completes
+		batch, ok := s.triggerLocked(group)
+		group.Mutex.Unlock()
+		if ok {
+			flushed++
+			go s.processAggregationGroupWithBatch(group, batch, TriggerFlush)
+		}
+	}
+
+	s.logger.AggregationFlushed(flushed)
+	return flushed
+}
+
+// Shutdown waits for in-flight batches and pending database writes until
+// ctx expires. Call FlushAll first so no batch is left waiting on a timer.
+func (s *WeatherService) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if err := waitContext(ctx, &s.batches); err != nil {
+		return fmt.Errorf("in-flight batches not drained: %w", err)
 	}
+	if err := s.writer.Close(ctx); err != nil {
+		return fmt.Errorf("pending database writes not drained: %w", err)
+	}
+	return nil
+}
+
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WriterStats reports the database write queue counters
+func (s *WeatherService) WriterStats() database.WriterStats {
+	return s.writer.Stats()
+}
+
+// RequiredProviders is the effective quorum for the registered providers
+func (s *WeatherService) RequiredProviders() int {
+	providerCount := s.providers.Len()
+	if s.quorum <= 0 || s.quorum > providerCount {
+		return providerCount
+	}
+	return s.quorum
+}
+
+// ProviderStatuses reports health and circuit breaker state per provider
+func (s *WeatherService) ProviderStatuses() []clients.ProviderStatus {
+	return s.providers.Statuses()
 }
 
-func (s *WeatherService) GetWeather(location string) (*types.WeatherResponse, error) {
-	group := s.getOrCreateAggregationGroup(location)
+func newNormalizer(cfg *config.Config) *location.Normalizer {
+	aliases := make(map[string]string, len(location.DefaultAliases)+len(cfg.LocationAliases))
+	for from, to := range location.DefaultAliases {
+		aliases[from] = to
+	}
+	for from, to := range cfg.LocationAliases {
+		aliases[from] = to
+	}
+	return location.NewNormalizer(aliases, cfg.CoordinatePrecision)
+}
+
+// GetWeather joins the aggregation group for the canonical form of query and
+// waits for the shared result, echoing the caller's query as the location.
+// If ctx ends first the caller leaves the group; the upstream call is only
+// cancelled once every caller in its batch has left.
+func (s *WeatherService) GetWeather(ctx context.Context, query string) (*types.WeatherResponse, error) {
+	key := s.normalizer.Key(query)
+	if key == "" {
+		return nil, fmt.Errorf("location is empty")
+	}
+
+	response, err := s.getWeatherByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	response.Location = query
+	return response, nil
+}
+
+func (s *WeatherService) getWeatherByKey(ctx context.Context, location string) (response *types.WeatherResponse, err error) {
+	result := "success"
+	defer func() {
+		switch {
+		case err != nil && ctx.Err() != nil:
+			result = "cancelled"
+		case err != nil:
+			result = "error"
+		}
+		metrics.Requests.WithLabelValues(s.locationBucket(location), result).Inc()
+	}()
+
+	// Cache hit: answer immediately without entering the aggregation window
+	if cached, ok := s.cachedResponse(location); ok {
+		result = "cache_hit"
+		trace.SpanFromContext(ctx).AddEvent("cache_hit")
+		return cached, nil
+	}
+
+	// the batch span links to this one; it covers joining the group up to the answer
+	ctx, span := tracing.Tracer().Start(ctx, "aggregation.wait",
+		trace.WithAttributes(attribute.String("location", location)))
+	defer func() { tracing.End(span, err) }()
 
 	responseChan := make(chan types.WeatherResponse, 1)
 	errorChan := make(chan error, 1)
 	
 	request := types.AggregationRequest{
+		Context:  ctx,
+		JoinedAt: time.Now(),
 		Location: location,
 		Response: responseChan,
 		Error:    errorChan,
 	}	
+	group, err := s.getOrCreateAggregationGroup(location, 0)
+	for err == nil && !s.joinGroup(group, request) {
+		group, err = s.getOrCreateAggregationGroup(location, 0)
+	}
+	if errors.Is(err, errOverflowDirect) {
+		return s.handleNewRequestImmediately(ctx, location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.waitForResponse(ctx, group, request)
+}
+
+// joinGroup adds request to group and starts its timer or fires the batch
+// right away, exactly as for a /weather caller. It returns false if the
+// group was evicted after it was looked up; the caller fetches a fresh one.
+func (s *WeatherService) joinGroup(group *AggregationGroup, request types.AggregationRequest) bool {
 	group.Mutex.Lock()
 	
+	if group.Evicted {
+		group.Mutex.Unlock()
+		return false
+	}
+	group.LastUsed = request.JoinedAt
+	
 	// Eğer group processing durumundaysa, yeni request ekleme
 	if group.IsProcessing {
 		// Processing durumundaki gruplara yeni request eklemiyoruz
 		//  processing sırasında gelenler aynı grup içinde birikir ve timer ile yeni batch açılır.
-		startTimer := (group.Timer == nil)
 		group.Requests = append(group.Requests, request)
-		if startTimer {
-			group.Timer = time.AfterFunc(group.WaitTime, func() {
-				group.Mutex.Lock()
-				batch, ok := s.triggerLocked(group)
-				group.Mutex.Unlock()
-				if !ok {
-					return
-				}
-				s.processAggregationGroupWithBatch(group, batch)
-			})
-		}
+		s.startTimerLocked(group)
 		group.Mutex.Unlock()
-		return s.waitForResponse(responseChan, errorChan)
+		return true
 	}
 	
 	group.Requests = append(group.Requests, request)
 	requestCount := len(group.Requests)
-	isFirstRequest := (requestCount == 1)
+	maxReached := group.Strategy.ShouldFire(requestCount)
 	
-	// Max request limitine ulaşıldığında hemen işle
-	if requestCount >= group.MaxRequests {
-		s.logger.AggregationMaxReached(location, requestCount)
+	// Max request limitine ulaşıldığında ya da shutdown sırasında hemen işle
+	if maxReached || s.draining.Load() {
+		if maxReached {
+			s.logger.Ctx(request.Context).AggregationMaxReached(group.Key, requestCount)
+		}
 		if group.Timer != nil {
 			group.Timer.Stop()
 			group.Timer = nil
 		}
+		trigger := TriggerMaxReached
+		if !maxReached {
+			trigger = TriggerFlush
+		}
 		batch, ok := s.triggerLocked(group)
 		group.Mutex.Unlock()
 		if ok {
-			go s.processAggregationGroupWithBatch(group, batch)
+			go s.processAggregationGroupWithBatch(group, batch, trigger)
 		}
-		return s.waitForResponse(responseChan, errorChan)
+		return true
 	}
 	
-	// İlk request ise timer başlat
-	if isFirstRequest {
-		group.Timer = time.AfterFunc(group.WaitTime, func() {
-			group.Mutex.Lock()
-			batch, ok := s.triggerLocked(group)
-			group.Mutex.Unlock()
-			if !ok {
-				return
-			}
-			s.processAggregationGroupWithBatch(group, batch)
-		})
-	}
+	// İlk request timer'ı başlatır; debounce gibi stratejilerde sonrakiler onu öteler
+	s.startTimerLocked(group)
 	
 	group.Mutex.Unlock()
-	return s.waitForResponse(responseChan, errorChan)
+	return true
 }
 
 // handleNewRequestImmediately handles requests when the current group is processing
-func (s *WeatherService) handleNewRequestImmediately(location string, responseChan chan types.WeatherResponse, errorChan chan error) (*types.WeatherResponse, error) {
-	s.logger.Info().
+func (s *WeatherService) handleNewRequestImmediately(ctx context.Context, location string) (*types.WeatherResponse, error) {
+	s.logger.Ctx(ctx).Info().
 		Str("component", "aggregation").
 		Str("action", "immediate_processing").
 		Str("location", location).
 		Msg("Processing request immediately")
 	
 	// Fetch weather data directly without aggregation
-	weatherData, err := s.fetchWeatherData(location, 1)
+	fetchStart := time.Now()
+	weatherData, err := s.fetchWeatherData(ctx, location, 1)
 	if err != nil {
-		s.logger.Error().
+		s.logger.Ctx(ctx).Error().
 			Str("component", "aggregation").
 			Str("action", "immediate_processing_error").
 			Str("location", location).
@@ -136,13 +369,15 @@ func (s *WeatherService) handleNewRequestImmediately(location string, responseCh
 			Msg("Weather data not fetched in immediate processing")
 		return nil, err
 	}
+	s.recordFetch(ctx, weatherData, callerTenants(ctx), TriggerDirect, 0, time.Since(fetchStart))
 	
 	response := types.WeatherResponse{
 		Location:    weatherData.Location,
 		Temperature: weatherData.AverageTemp,
+		Conditions:  weatherData.Conditions,
 	}
 	
-	s.logger.Info().
+	s.logger.Ctx(ctx).Info().
 		Str("component", "aggregation").
 		Str("action", "immediate_processing_completed").
 		Str("location", location).
@@ -151,24 +386,34 @@ func (s *WeatherService) handleNewRequestImmediately(location string, responseCh
 	return &response, nil
 }
 
-func (s *WeatherService) getOrCreateAggregationGroup(location string) *AggregationGroup {
+// getOrCreateAggregationGroup returns the /weather group for location, or
+// the /forecast group for location and days when days > 0.
+func (s *WeatherService) getOrCreateAggregationGroup(location string, days int) (*AggregationGroup, error) {
 	s.aggregationMutex.Lock()
 	defer s.aggregationMutex.Unlock()
 	
-	group, exists := s.aggregationMap[location]
+	key := groupKey(location, days)
+	group, exists := s.aggregationMap[key]
 	if !exists {
+		if err := s.admitGroupLocked(key); err != nil {
+			return nil, err
+		}
 		group = &AggregationGroup{
+			Key:          key,
 			Location:     location,
+			Days:         days,
 			Requests:     make([]types.AggregationRequest, 0),
-			MaxRequests:  s.maxRequests,
-			WaitTime:     s.waitTime,
+			Strategy:     s.strategyFor(location),
+			CreatedAt:    time.Now(),
+			LastUsed:     time.Now(),
 			IsProcessing: false,
 		}
-		s.aggregationMap[location] = group
-		s.logger.AggregationGroupCreated(location)
+		s.aggregationMap[key] = group
+		metrics.Groups.Set(float64(len(s.aggregationMap)))
+		s.logger.AggregationGroupCreated(key)
 	}
 	
-	return group
+	return group, nil
 }
 
 func (s *WeatherService) processAggregationGroup(group *AggregationGroup) {
@@ -189,7 +434,9 @@ func (s *WeatherService) processAggregationGroup(group *AggregationGroup) {
 	
 	s.logger.AggregationProcessing(group.Location, requestCount)
 	
-	weatherData, err := s.fetchWeatherData(group.Location, requestCount)
+	ctx, cancel := batchContext(requests)
+	weatherData, err := s.fetchWeatherData(ctx, group.Location, requestCount)
+	cancel()
 	if err != nil {
 		s.logger.Error().
 			Str("component", "aggregation").
@@ -208,15 +455,7 @@ func (s *WeatherService) processAggregationGroup(group *AggregationGroup) {
 		group.IsProcessing = false
 		// Eğer bekleyen istekler varsa ve timer yoksa yeni batch için timer başlat
 		if len(group.Requests) > 0 && group.Timer == nil {
-			group.Timer = time.AfterFunc(group.WaitTime, func() {
-				group.Mutex.Lock()
-				batch, ok := s.triggerLocked(group)
-				group.Mutex.Unlock()
-				if !ok {
-					return
-				}
-				s.processAggregationGroupWithBatch(group, batch)
-			})
+			s.startTimerLocked(group)
 		}
 		group.Mutex.Unlock()
 		return
@@ -225,7 +464,9 @@ func (s *WeatherService) processAggregationGroup(group *AggregationGroup) {
 	response := types.WeatherResponse{
 		Location:    weatherData.Location,
 		Temperature: weatherData.AverageTemp,
+		Conditions:  weatherData.Conditions,
 	}
+	s.cacheResponse(group.Location, response)
 	
 	for _, req := range requests {
 		req.Response <- response
@@ -244,187 +485,435 @@ func (s *WeatherService) processAggregationGroup(group *AggregationGroup) {
 	group.IsProcessing = false
 	// Eğer bekleyen istekler varsa ve timer yoksa yeni batch için timer başlasın
 	if len(group.Requests) > 0 && group.Timer == nil {
-		group.Timer = time.AfterFunc(group.WaitTime, func() {
-			group.Mutex.Lock()
-			batch, ok := s.triggerLocked(group)
-			group.Mutex.Unlock()
-			if !ok {
-				return
-			}
-			s.processAggregationGroupWithBatch(group, batch)
-		})
+		s.startTimerLocked(group)
 	}
 	group.Mutex.Unlock()
 }
 
-// cleanupAggregationGroup removes the group from the map and resets its state
-func (s *WeatherService) cleanupAggregationGroup(group *AggregationGroup) {
+// cleanupAggregationGroup removes the group from the map and fails the
+// requests still waiting in it with ErrGroupEvicted. A batch already in
+// flight still answers its own callers. Returns how many waiters were dropped.
+func (s *WeatherService) cleanupAggregationGroup(group *AggregationGroup) int {
+	s.aggregationMutex.Lock()
 	group.Mutex.Lock()
-	group.Requests = make([]types.AggregationRequest, 0)
-	group.Timer = nil
-	group.IsProcessing = false
+	if s.aggregationMap[group.Key] == group {
+		delete(s.aggregationMap, group.Key)
+		metrics.Groups.Set(float64(len(s.aggregationMap)))
+	}
+	group.Evicted = true
+	if group.Timer != nil {
+		group.Timer.Stop()
+		group.Timer = nil
+	}
+	pending := group.Requests
+	group.Requests = nil
 	group.Mutex.Unlock()
-	
-	// Remove from aggregation map
-	s.aggregationMutex.Lock()
-	delete(s.aggregationMap, group.Location)
 	s.aggregationMutex.Unlock()
 	
+	for _, req := range pending {
+		req.Error <- ErrGroupEvicted
+	}
+	
 	s.logger.Debug().
 		Str("component", "aggregation").
 		Str("action", "group_cleaned").
-		Str("location", group.Location).
+		Str("location", group.Key).
+		Int("dropped_requests", len(pending)).
 		Msg("Aggregation group cleaned up")
+	return len(pending)
 }
 
-// fetch data 
-func (s *WeatherService) fetchWeatherData(location string, requestCount int) (*types.WeatherData, error) {
-	
-	var service1Temp, service2Temp float64
-	var service1Err, service2Err error
+// fetch data from every registered provider and average the successful results
+func (s *WeatherService) fetchWeatherData(ctx context.Context, location string, requestCount int) (*types.WeatherData, error) {
+	providers := s.providers.Providers()
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no weather providers registered")
+	}
+
+	results := make([]types.ProviderResult, len(providers))
+	reported := make([]*types.Conditions, len(providers))
+	errs := make([]error, len(providers))
 	
 	var wg sync.WaitGroup
-	wg.Add(2)
-	
-	// weatherapi
-	go func() {
-		defer wg.Done()
-		service1Temp, service1Err = s.weatherAPIClient.GetTemperature(location)
-	}()
-	
-	// weather stack
-	go func() {
-		defer wg.Done()
-		service2Temp, service2Err = s.weatherStackClient.GetTemperature(location)
-	}()
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider clients.WeatherProvider) {
+			defer wg.Done()
+			results[i].Provider = provider.Name()
+			providerCtx, span := tracing.Tracer().Start(ctx, "provider.current", trace.WithAttributes(
+				attribute.String("provider", provider.Name()),
+				attribute.String("location", location),
+			))
+			startTime := time.Now()
+			conditions, err := provider.GetCurrent(providerCtx, location)
+			latency := time.Since(startTime)
+			results[i].LatencyMs = latency.Milliseconds()
+			metrics.ProviderLatency.WithLabelValues(provider.Name()).Observe(latency.Seconds())
+			tracing.End(span, err)
+			if err != nil {
+				// abandoned batches aren't upstream failures
+				if !errors.Is(err, context.Canceled) {
+					metrics.ProviderErrors.WithLabelValues(provider.Name()).Inc()
+				}
+				errs[i] = err
+				results[i].Error = err.Error()
+				return
+			}
+			temperature := conditions.Temperature
+			results[i].Temperature = &temperature
+			reported[i] = conditions
+		}(i, provider)
+	}
 	
 	wg.Wait()
 	
-	if service1Err != nil {
-		return nil, fmt.Errorf("WeatherAPI.com hatası: %v", service1Err)
+	var contributors []string
+	var contributing []*types.Conditions
+	for i, result := range results {
+		if errs[i] != nil {
+			if ctx.Err() == nil {
+				s.logger.Ctx(ctx).AggregationProviderFailed(location, result.Provider, errs[i])
+			}
+			continue
+		}
+		contributors = append(contributors, result.Provider)
+		contributing = append(contributing, reported[i])
 	}
-	if service2Err != nil {
-		return nil, fmt.Errorf("WeatherStack.com hatası: %v", service2Err)
+
+	quorum := s.RequiredProviders()
+	if len(contributors) < quorum {
+		return nil, fmt.Errorf("provider quorum not met (%d/%d succeeded, need %d): %v",
+			len(contributors), len(providers), quorum, errors.Join(errs...))
 	}
-	averageTemp := (service1Temp + service2Temp) / 2
+	conditions := mergeConditions(contributing)
+
+	// weather_queries keeps two temperature columns; they map to the first two providers
+	service1Temp := providerTemperature(results, 0)
+	service2Temp := providerTemperature(results, 1)
 	
 	weatherData := &types.WeatherData{
 		Location:     location,
 		Service1Temp: service1Temp,
 		Service2Temp: service2Temp,
-		AverageTemp:  averageTemp,
+		AverageTemp:  conditions.Temperature,
+		Conditions:   conditions,
 		RequestCount: requestCount,
+		Providers:    results,
+		Contributors: contributors,
 	}
 	
-	// async save to database
-	go func() {
-		query := &types.WeatherQuery{
-			Location:     location,
-			Service1Temp: service1Temp,
-			Service2Temp: service2Temp,
-			RequestCount: requestCount,
-		}
-		
-		if err := s.database.SaveWeatherQuery(query); err != nil {
-			s.logger.DatabaseError("save_weather_query", err)
-		} else {
-			s.logger.DatabaseSave(location, service1Temp, service2Temp, requestCount)
-		}
-	}()
-	
 	return weatherData, nil
 }
 
+// recordFetch queues the weather_queries row of one upstream fetch: who
+// triggered it, which tenants it served, how long its oldest caller waited
+// for it to start, and how each provider did. Queued for the background
+// writer; never blocks on the database.
+func (s *WeatherService) recordFetch(ctx context.Context, data *types.WeatherData, tenants []string, trigger string, waited, fetchTime time.Duration) {
+	waitMs, fetchMs := waited.Milliseconds(), fetchTime.Milliseconds()
+	query := &types.WeatherQuery{
+		Location:          data.Location,
+		Service1Temp:      data.Service1Temp,
+		Service2Temp:      data.Service2Temp,
+		RequestCount:      data.RequestCount,
+		Providers:         strings.Join(data.Contributors, ","),
+		TriggerReason:     trigger,
+		WaitMs:            &waitMs,
+		FetchMs:           &fetchMs,
+		ProviderLatencies: make(map[string]int64, len(data.Providers)),
+		Tenants:           tenants,
+	}
+	for _, result := range data.Providers {
+		query.ProviderLatencies[result.Provider] = result.LatencyMs
+		if result.Error != "" {
+			if query.ProviderErrors == nil {
+				query.ProviderErrors = make(map[string]string)
+			}
+			query.ProviderErrors[result.Provider] = result.Error
+		}
+	}
+	s.writer.Enqueue(ctx, query)
+}
+
 
-func (s *WeatherService) waitForResponse(responseChan chan types.WeatherResponse, errorChan chan error) (*types.WeatherResponse, error) {
+func (s *WeatherService) waitForResponse(ctx context.Context, group *AggregationGroup, request types.AggregationRequest) (*types.WeatherResponse, error) {
 	select {
-	case response := <-responseChan:
+	case response := <-request.Response:
 		return &response, nil
-	case err := <-errorChan:
+	case err := <-request.Error:
 		return nil, err
+	case <-ctx.Done():
+		s.leaveGroup(group, request)
+		return nil, ctx.Err()
+	}
+}
+
+// leaveGroup drops a caller that gave up while still waiting for its batch.
+// Callers already handed to a batch are covered by batchContext instead.
+func (s *WeatherService) leaveGroup(group *AggregationGroup, request types.AggregationRequest) {
+	group.Mutex.Lock()
+	defer group.Mutex.Unlock()
+
+	for i, req := range group.Requests {
+		if req.Error != request.Error {
+			continue
+		}
+		group.Requests = append(group.Requests[:i], group.Requests[i+1:]...)
+		if len(group.Requests) == 0 && group.Timer != nil {
+			group.Timer.Stop()
+			group.Timer = nil
+		}
+		s.logger.Ctx(request.Context).AggregationRequestLeft(group.Key, len(group.Requests))
+		return
 	}
 }
 
 // ——— yardımcı fonksiyonlar (yorum eklemeden) ———
 
+func (s *WeatherService) locationBucket(location string) string {
+	if s.trackedLocations[location] {
+		return location
+	}
+	return "other"
+}
+
+func (s *WeatherService) cachedResponse(location string) (*types.WeatherResponse, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	response, ok := s.cache.Get(location)
+	if !ok {
+		return nil, false
+	}
+	s.logger.CacheHit(location)
+	return &response, true
+}
+
+func (s *WeatherService) cacheResponse(location string, response types.WeatherResponse) {
+	if s.cache != nil {
+		s.cache.Set(location, response)
+	}
+}
+
+// batchContext is cancelled once every caller in the batch has gone away,
+// so upstream calls keep running as long as anyone is still waiting.
+func batchContext(batch []types.AggregationRequest) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	remaining := int32(len(batch))
+	stops := make([]func() bool, 0, len(batch))
+	for _, req := range batch {
+		if req.Context == nil {
+			continue
+		}
+		stops = append(stops, context.AfterFunc(req.Context, func() {
+			if atomic.AddInt32(&remaining, -1) == 0 {
+				cancel()
+			}
+		}))
+	}
+
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+// startBatchSpan starts the span of one upstream round trip. A batch serves
+// many requests, so it starts a new trace linked to the wait span of every
+// member, and each member's wait span gets an event naming the batch trace.
+func startBatchSpan(ctx context.Context, group *AggregationGroup, batch []types.AggregationRequest, trigger string) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(batch))
+	requestIDs := make([]string, 0, len(batch))
+	for _, req := range batch {
+		if req.Context == nil {
+			continue
+		}
+		links = append(links, trace.LinkFromContext(req.Context))
+		if requestID := logger.RequestID(req.Context); requestID != "" {
+			requestIDs = append(requestIDs, requestID)
+		}
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "aggregation.batch",
+		trace.WithNewRoot(),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("aggregation.group", group.Key),
+			attribute.String("aggregation.trigger", trigger),
+			attribute.Int("aggregation.request_count", len(batch)),
+		))
+
+	batchTrace := attribute.String("aggregation.batch_trace_id", span.SpanContext().TraceID().String())
+	for _, req := range batch {
+		if req.Context != nil {
+			trace.SpanFromContext(req.Context).AddEvent("batch_started", trace.WithAttributes(batchTrace))
+		}
+	}
+	return logger.WithRequestIDs(ctx, requestIDs), span
+}
+
+// callerTenants lists the tenants of the requests a fetch served, once
+// each. Anonymous callers aren't recorded.
+func callerTenants(contexts ...context.Context) []string {
+	var tenants []string
+	for _, ctx := range contexts {
+		if ctx == nil {
+			continue
+		}
+		tenantID := middleware.TenantFromContext(ctx)
+		if tenantID != middleware.AnonymousTenant && !slices.Contains(tenants, tenantID) {
+			tenants = append(tenants, tenantID)
+		}
+	}
+	return tenants
+}
+
+func providerTemperature(results []types.ProviderResult, index int) *float64 {
+	if index >= len(results) {
+		return nil
+	}
+	return results[index].Temperature
+}
+
+// startTimerLocked schedules the next batch for group at the deadline its
+// strategy picks for the pending requests, or right away while the service
+// is draining. A running timer is only replaced when the deadline moved.
+func (s *WeatherService) startTimerLocked(group *AggregationGroup) {
+	if len(group.Requests) == 0 {
+		return
+	}
+	deadline, trigger := group.Strategy.Deadline(group.Requests[0].JoinedAt, group.Requests[len(group.Requests)-1].JoinedAt), TriggerTimer
+	if s.draining.Load() {
+		deadline, trigger = time.Now(), TriggerFlush
+	}
+	if group.Timer != nil {
+		if deadline.Equal(group.Deadline) {
+			return
+		}
+		group.Timer.Stop()
+	}
+	group.Deadline = deadline
+	group.Timer = time.AfterFunc(time.Until(deadline), func() {
+		group.Mutex.Lock()
+		batch, ok := s.triggerLocked(group)
+		group.Mutex.Unlock()
+		if !ok {
+			return
+		}
+		s.processAggregationGroupWithBatch(group, batch, trigger)
+	})
+}
+
 func (s *WeatherService) triggerLocked(group *AggregationGroup) ([]types.AggregationRequest, bool) {
 	if group.Timer != nil {
 		group.Timer.Stop()
 		group.Timer = nil
 	}
-	if group.IsProcessing {
+	if group.IsProcessing || len(group.Requests) == 0 {
 		return nil, false
 	}
 	group.IsProcessing = true
+	s.batches.Add(1)
 	batch := make([]types.AggregationRequest, len(group.Requests))
 	copy(batch, group.Requests)
 	group.Requests = nil
 	return batch, true
 }
 
-func (s *WeatherService) processAggregationGroupWithBatch(group *AggregationGroup, batch []types.AggregationRequest) {
+func (s *WeatherService) processAggregationGroupWithBatch(group *AggregationGroup, batch []types.AggregationRequest, trigger string) {
+	defer s.batches.Done()
+
 	requestCount := len(batch)
-	s.logger.AggregationProcessing(group.Location, requestCount)
+	ctx, cancel := batchContext(batch)
+	ctx, span := startBatchSpan(ctx, group, batch, trigger)
+	log := s.logger.Ctx(ctx)
+	log.AggregationProcessing(group.Key, requestCount)
+
+	metrics.Batches.WithLabelValues(trigger).Inc()
+	metrics.BatchSize.Observe(float64(requestCount))
+	var waited time.Duration // of the oldest member, logged with the fetch
+	for _, req := range batch {
+		wait := time.Since(req.JoinedAt)
+		metrics.WaitTime.Observe(wait.Seconds())
+		waited = max(waited, wait)
+	}
 
-	weatherData, err := s.fetchWeatherData(group.Location, requestCount)
+	fetchStart := time.Now()
+	var err error
+	if group.Days > 0 {
+		err = s.completeForecastBatch(ctx, group, batch)
+	} else {
+		err = s.completeWeatherBatch(ctx, group, batch, trigger, waited)
+	}
+	fetchTime := time.Since(fetchStart)
+	cancelled := ctx.Err() != nil
+	cancel()
+	tracing.End(span, err)
 	if err != nil {
-		s.logger.Error().
-			Str("component", "aggregation").
-			Str("action", "fetch_weather_error_batch").
-			Str("location", group.Location).
-			Int("request_count", requestCount).
-			Err(err).
-			Msg("Weather data not fetched in batch processing")
+		if cancelled {
+			log.AggregationBatchCancelled(group.Key, requestCount)
+		} else {
+			log.Error().
+				Str("component", "aggregation").
+				Str("action", "fetch_weather_error_batch").
+				Str("location", group.Key).
+				Int("request_count", requestCount).
+				Err(err).
+				Msg("Weather data not fetched in batch processing")
+		}
 		for _, req := range batch {
 			req.Error <- err
 		}
-		group.Mutex.Lock()
-		group.IsProcessing = false
-		if len(group.Requests) > 0 && group.Timer == nil {
-			group.Timer = time.AfterFunc(group.WaitTime, func() {
-				group.Mutex.Lock()
-				next, ok := s.triggerLocked(group)
-				group.Mutex.Unlock()
-				if !ok {
-					return
-				}
-				s.processAggregationGroupWithBatch(group, next)
-			})
-			s.logger.AggregationTimerStarted(group.Location, group.WaitTime)
-		}
-		group.Mutex.Unlock()
-		return
 	}
 
+	group.Mutex.Lock()
+	group.IsProcessing = false
+	group.LastUsed = time.Now()
+	if !cancelled {
+		group.Strategy.Observe(fetchTime)
+	}
+	if len(group.Requests) > 0 && group.Timer == nil {
+		s.startTimerLocked(group)
+		s.logger.AggregationTimerStarted(group.Key, time.Until(group.Deadline))
+	}
+	group.Mutex.Unlock()
+}
+
+// completeWeatherBatch fetches current conditions, logs the fetch and
+// answers the whole batch
+func (s *WeatherService) completeWeatherBatch(ctx context.Context, group *AggregationGroup, batch []types.AggregationRequest, trigger string, waited time.Duration) error {
+	requestCount := len(batch)
+	fetchStart := time.Now()
+	weatherData, err := s.fetchWeatherData(ctx, group.Location, requestCount)
+	if err != nil {
+		return err
+	}
+	contexts := make([]context.Context, 0, len(batch))
+	for _, req := range batch {
+		contexts = append(contexts, req.Context)
+	}
+	s.recordFetch(ctx, weatherData, callerTenants(contexts...), trigger, waited, time.Since(fetchStart))
+
 	response := types.WeatherResponse{
 		Location:    weatherData.Location,
 		Temperature: weatherData.AverageTemp,
+		Conditions:  weatherData.Conditions,
 	}
+	s.cacheResponse(group.Location, response)
 	for _, req := range batch {
 		req.Response <- response
 	}
 
-	s.logger.Info().
+	s.logger.Ctx(ctx).Info().
 		Str("component", "aggregation").
 		Str("action", "batch_completed").
 		Str("location", group.Location).
 		Float64("temperature", weatherData.AverageTemp).
+		Strs("providers", weatherData.Contributors).
 		Int("request_count", requestCount).
 		Msg("Batch processing completed")
-
-	group.Mutex.Lock()
-	group.IsProcessing = false
-	if len(group.Requests) > 0 && group.Timer == nil {
-		group.Timer = time.AfterFunc(group.WaitTime, func() {
-			group.Mutex.Lock()
-			next, ok := s.triggerLocked(group)
-			group.Mutex.Unlock()
-			if !ok {
-				return
-			}
-			s.processAggregationGroupWithBatch(group, next)
-		})
-		s.logger.AggregationTimerStarted(group.Location, group.WaitTime)
-	}
-	group.Mutex.Unlock()
+	return nil
 }
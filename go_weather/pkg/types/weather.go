@@ -1,57 +1,192 @@
 package types
 
+import (
+	"context"
+	"time"
+)
+
 // WeatherRequest 
 type WeatherRequest struct {
 	Location string `json:"location" validate:"required"`
 }
 
-// WeatherResponse
+// WeatherResponse; Conditions carries the averaged extras the handler picks from with fields=
 type WeatherResponse struct {
-	Location    string  `json:"location"`
-	Temperature float64 `json:"temperature"`
+	Location    string     `json:"location"`
+	Temperature float64    `json:"temperature"`
+	Conditions  Conditions `json:"-"`
 }
 
 // WeatherData Combined
 type WeatherData struct {
 	Location         string  `json:"location"`
-	Service1Temp     float64 `json:"service_1_temperature"`
-	Service2Temp     float64 `json:"service_2_temperature"`
-	AverageTemp      float64 `json:"average_temperature"`
-	RequestCount     int     `json:"request_count"`
+	Service1Temp     *float64 `json:"service_1_temperature"`
+	Service2Temp     *float64 `json:"service_2_temperature"`
+	AverageTemp      float64  `json:"average_temperature"`
+	Conditions       Conditions `json:"conditions"`
+	RequestCount     int      `json:"request_count"`
+	Providers        []ProviderResult `json:"providers"`
+	Contributors     []string `json:"contributors"`
+}
+
+// ProviderResult single provider outcome inside a batch; Temperature is nil when the provider failed
+type ProviderResult struct {
+	Provider    string   `json:"provider"`
+	Temperature *float64 `json:"temperature"`
+	Error       string   `json:"error,omitempty"`
+	LatencyMs   int64    `json:"latency_ms"`
+}
+
+// Conditions normalized current conditions returned by every provider.
+// Units: °C, km/h, degrees, mb; optional values are nil when the provider doesn't report them.
+type Conditions struct {
+	Temperature   float64    `json:"temperature"`
+	FeelsLike     *float64   `json:"feels_like,omitempty"`
+	Humidity      *float64   `json:"humidity,omitempty"`
+	WindSpeed     *float64   `json:"wind_speed,omitempty"`
+	WindDirection *float64   `json:"wind_direction,omitempty"`
+	Pressure      *float64   `json:"pressure,omitempty"`
+	ConditionText string     `json:"condition,omitempty"`
+	ConditionCode *int       `json:"condition_code,omitempty"` // WWO weather code, shared by both providers
+	ObservedAt    *time.Time `json:"observed_at,omitempty"`
 }
 
 // DB Query Schema
 type WeatherQuery struct {
 	ID                int     `json:"id" db:"id"`
 	Location          string  `json:"location" db:"location"`
-	Service1Temp      float64 `json:"service_1_temperature" db:"service_1_temperature"`
-	Service2Temp      float64 `json:"service_2_temperature" db:"service_2_temperature"`
+	Service1Temp      *float64 `json:"service_1_temperature" db:"service_1_temperature"`
+	Service2Temp      *float64 `json:"service_2_temperature" db:"service_2_temperature"`
 	RequestCount      int     `json:"request_count" db:"request_count"`
+	Providers         string  `json:"providers" db:"providers"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	TriggerReason     string            `json:"trigger_reason,omitempty" db:"trigger_reason"`
+	WaitMs            *int64            `json:"wait_ms" db:"wait_ms"`
+	FetchMs           *int64            `json:"fetch_ms" db:"fetch_ms"`
+	ProviderLatencies map[string]int64  `json:"provider_latencies,omitempty" db:"provider_latencies"`
+	ProviderErrors    map[string]string `json:"provider_errors,omitempty" db:"provider_errors"`
+	Tenants           []string          `json:"-" db:"-"` // tenants served, kept in weather_query_tenants
+}
+
+// HistoryPage is one page of /history; NextCursor is empty on the last page
+type HistoryPage struct {
+	Items      []WeatherQuery `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// HistoryStats summarises the weather_queries rows matching a /history filter.
+// Each row is one upstream fetch that served RequestCount callers.
+type HistoryStats struct {
+	Location      string     `json:"location,omitempty"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	Fetches       int        `json:"upstream_fetches"`
+	TotalRequests int        `json:"total_requests"`
+	AvgBatchSize  float64    `json:"avg_batch_size"`
+	MinTemp       *float64   `json:"min_temperature"`
+	MaxTemp       *float64   `json:"max_temperature"`
+	AvgTemp       *float64   `json:"avg_temperature"`
 }
 
 // WeatherAPIResponse
 type WeatherAPIResponse struct {
 	Current struct {
-		TempC float64 `json:"temp_c"`
+		TempC            float64  `json:"temp_c"`
+		FeelsLikeC       *float64 `json:"feelslike_c"`
+		Humidity         *float64 `json:"humidity"`
+		WindKph          *float64 `json:"wind_kph"`
+		WindDegree       *float64 `json:"wind_degree"`
+		PressureMb       *float64 `json:"pressure_mb"`
+		LastUpdatedEpoch int64    `json:"last_updated_epoch"`
+		Condition        struct {
+			Text string `json:"text"`
+			Icon string `json:"icon"` // ".../day/113.png", the number is the WWO code
+			Code int    `json:"code"`
+		} `json:"condition"`
 	} `json:"current"`
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"` // YYYY-MM-DD, location's local date
+			Day  struct {
+				MaxTempC float64 `json:"maxtemp_c"`
+				MinTempC float64 `json:"mintemp_c"`
+				AvgTempC float64 `json:"avgtemp_c"`
+			} `json:"day"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
 }
 
 // WeatherStackResponse
 type WeatherStackResponse struct {
+	// failures come back as HTTP 200 with success=false and an error object
+	Success *bool              `json:"success"`
+	Error   *WeatherStackError `json:"error"`
 	Current struct {
-		Temperature float64 `json:"temperature"`
+		Temperature         float64  `json:"temperature"`
+		FeelsLike           *float64 `json:"feelslike"`
+		Humidity            *float64 `json:"humidity"`
+		WindSpeed           *float64 `json:"wind_speed"`
+		WindDegree          *float64 `json:"wind_degree"`
+		Pressure            *float64 `json:"pressure"`
+		WeatherCode         *int     `json:"weather_code"`
+		WeatherDescriptions []string `json:"weather_descriptions"`
+		ObservationTime     string   `json:"observation_time"` // "02:15 PM", UTC
 	} `json:"current"`
 }
 
+// WeatherStackError is the error object of a failed Weatherstack request
+type WeatherStackError struct {
+	Code int    `json:"code"`
+	Type string `json:"type"`
+	Info string `json:"info"`
+}
+
 // ErrorResponse
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 }
 
+// ForecastDay daily temperatures in °C; averaged across providers in ForecastResponse
+type ForecastDay struct {
+	Date         string   `json:"date"`
+	MinTemp      float64  `json:"min_temperature"`
+	MaxTemp      float64  `json:"max_temperature"`
+	AvgTemp      float64  `json:"avg_temperature"`
+	Contributors []string `json:"providers,omitempty"`
+}
+
+// ForecastResponse
+type ForecastResponse struct {
+	Location string        `json:"location"`
+	Days     int           `json:"days"`
+	Forecast []ForecastDay `json:"forecast"`
+}
+
+// Tenant is a caller billed by usage; a quota of 0 means unlimited
+type Tenant struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	DailyQuota   int       `json:"daily_quota"`
+	MonthlyQuota int       `json:"monthly_quota"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TenantUsage request counts for the current day and month
+type TenantUsage struct {
+	TenantID   string `json:"tenant_id"`
+	Day        string `json:"day"`
+	DayCount   int    `json:"day_count"`
+	Month      string `json:"month"`
+	MonthCount int    `json:"month_count"`
+}
+
 // AggregationRequest
 type AggregationRequest struct {
+	Context   context.Context
+	JoinedAt  time.Time
 	Location  string
 	Response  chan WeatherResponse
+	Forecast  chan ForecastResponse // set instead of Response for forecast groups
 	Error     chan error
 }
@@ -157,7 +157,7 @@ func testIstanbul() []TestResult {
 }
 
 func testAnkara() []TestResult {
-	fmt.Println("\n" + "================================================================================\n")
+	fmt.Print("\n" + "================================================================================\n\n")
 	fmt.Println("TEST 2: Ankara ")
 	fmt.Printf("Start time: %s\n\n", time.Now().Format("15:04:05.000"))
 